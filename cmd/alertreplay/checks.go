@@ -0,0 +1,27 @@
+package main
+
+import (
+	"time"
+
+	"github.com/steved/alertreplay/internal/alert"
+	"github.com/steved/alertreplay/internal/check"
+)
+
+// LimitFlags fail the replay commands when the replayed alerts exceed them.
+type LimitFlags struct {
+	MaxAlerts      *int     `help:"Fail if more alerts fired." name:"max-alerts" placeholder:"N" group:"Checks"`
+	MaxFiringRatio *float64 `help:"Fail if alerts fired for a larger share of the replayed range, e.g. 0.05." name:"max-firing-ratio" placeholder:"ratio" group:"Checks"`
+	MaxFlaps       *int     `help:"Fail if alerts opened again with the same labels more often." name:"max-flaps" placeholder:"N" group:"Checks"`
+}
+
+// Check returns a *check.Error if alerts replayed from from to to exceed the
+// limits.
+func (l *LimitFlags) Check(alerts []alert.Alert, from, to time.Time) error {
+	limits := check.ReplayLimits{
+		MaxAlerts:      l.MaxAlerts,
+		MaxFiringRatio: l.MaxFiringRatio,
+		MaxFlaps:       l.MaxFlaps,
+	}
+
+	return check.Result(limits.Check(alerts, from, to))
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/steved/alertreplay/internal/alert"
+	"github.com/steved/alertreplay/internal/check"
+)
+
+func TestLimitFlags_Check(t *testing.T) {
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	alerts := []alert.Alert{{OpenedAt: from, Labels: map[string]string{"job": "api"}}}
+
+	assert.NoError(t, (&LimitFlags{}).Check(alerts, from, from.Add(time.Hour)))
+	assert.NoError(t, (&LimitFlags{MaxAlerts: new(1)}).Check(alerts, from, from.Add(time.Hour)))
+
+	err := (&LimitFlags{MaxAlerts: new(0), MaxFiringRatio: new(0.5)}).Check(alerts, from, from.Add(time.Hour))
+
+	var checkErr *check.Error
+	require.ErrorAs(t, err, &checkErr)
+	assert.Equal(t, []check.Violation{
+		{Check: "max-alerts", Value: "1", Limit: "0"},
+		{Check: "max-firing-ratio", Value: "1", Limit: "0.5"},
+	}, checkErr.Violations)
+}
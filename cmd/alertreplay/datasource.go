@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/steved/alertreplay/internal/prometheus"
+)
+
+// DatasourceFlags select the datasource one side of a diff is replayed
+// against. Secrets can also be passed through the environment or read from
+// files, to keep them out of the process list and shell history.
+type DatasourceFlags struct {
+	URL             string `help:"Prometheus API URL. Defaults to --prometheus-url." name:"url"`
+	Tenant          string `help:"Tenant, sent as the X-Scope-OrgID header." name:"tenant"`
+	Username        string `help:"Basic auth username." name:"username"`
+	Password        string `help:"Basic auth password." name:"password" env:"PASSWORD" xor:"password"`
+	PasswordFile    string `help:"Read the basic auth password from this file." name:"password-file" type:"existingfile" placeholder:"file" xor:"password"`
+	BearerToken     string `help:"Bearer token." name:"bearer-token" env:"BEARER_TOKEN" xor:"bearer-token"`
+	BearerTokenFile string `help:"Read the bearer token from this file." name:"bearer-token-file" type:"existingfile" placeholder:"file" xor:"bearer-token"`
+}
+
+// IsSet reports whether any of the flags were passed.
+func (d DatasourceFlags) IsSet() bool {
+	return d != DatasourceFlags{}
+}
+
+// Client returns a client for the datasource.
+func (d DatasourceFlags) Client(g *Global) (*prometheus.APIClient, error) {
+	password, err := readSecret(d.Password, d.PasswordFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading password: %w", err)
+	}
+
+	bearerToken, err := readSecret(d.BearerToken, d.BearerTokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading bearer token: %w", err)
+	}
+
+	return prometheus.NewAuthAPIClient(d.url(g), g.Parallelism, prometheus.Auth{
+		Username:    d.Username,
+		Password:    password,
+		BearerToken: bearerToken,
+		Tenant:      d.Tenant,
+	})
+}
+
+// readSecret returns the contents of file without surrounding whitespace, or
+// value if no file is set.
+func readSecret(value, file string) (string, error) {
+	if file == "" {
+		return value, nil
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+// Name labels the datasource in the output by its host and tenant.
+func (d DatasourceFlags) Name(g *Global) string {
+	name := d.url(g)
+	if u, err := url.Parse(name); err == nil && u.Host != "" {
+		name = u.Host
+	}
+
+	if d.Tenant != "" {
+		name += "/" + d.Tenant
+	}
+
+	return name
+}
+
+func (d DatasourceFlags) url(g *Global) string {
+	if d.URL != "" {
+		return d.URL
+	}
+
+	return g.PrometheusURL
+}
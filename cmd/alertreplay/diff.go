@@ -1,94 +1,399 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"path/filepath"
 	"sync"
+	"time"
 
+	"github.com/VictoriaMetrics/metricsql"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/rulefmt"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/steved/alertreplay/internal/alert"
+	"github.com/steved/alertreplay/internal/check"
+	"github.com/steved/alertreplay/internal/dashboard"
+	"github.com/steved/alertreplay/internal/evaluator"
 	"github.com/steved/alertreplay/internal/output"
 	"github.com/steved/alertreplay/internal/prometheus"
 	"github.com/steved/alertreplay/internal/vmrule"
 )
 
 type DiffCmd struct {
-	File1        string   `arg:"" name:"file1" help:"First alert rules file (VMRule format)." required:""`
-	File2        string   `arg:"" name:"file2" help:"Second alert rules file (VMRule format)." required:""`
-	AlertName    string   `arg:"" name:"alert-name" help:"Name of the alert to compare." required:""`
-	IgnoreLabels []string `help:"Labels to ignore when comparing alerts." name:"ignore-labels"`
+	File1        string        `arg:"" name:"file1" help:"First alert rules file (VMRule format). Omit with --expr-left and --expr-right." optional:""`
+	File2        string        `arg:"" name:"file2" help:"Second alert rules file (VMRule format)." optional:""`
+	AlertName    string        `arg:"" name:"alert-name" help:"Name of the alert to compare. Omit with --all." optional:""`
+	AlertName2   string        `arg:"" name:"alert-name2" help:"Name of the alert in file2, if it differs from alert-name." optional:""`
+	All          bool          `help:"Compare every alert in the two files and print a summary per alert."`
+	ExprLeft     string        `help:"Compare this expression instead of an alert from file1." name:"expr-left" placeholder:"expr"`
+	ExprRight    string        `help:"Compare this expression instead of an alert from file2." name:"expr-right" placeholder:"expr"`
+	ForLeft      time.Duration `help:"'for' duration of --expr-left." name:"for-left"`
+	ForRight     time.Duration `help:"'for' duration of --expr-right." name:"for-right"`
+	IgnoreLabels []string      `help:"Labels to ignore when comparing alerts." name:"ignore-labels"`
+	CompareRange time.Time     `help:"Compare --from to --to with the period of the same length starting at this time, e.g. '14 days ago'." name:"compare-range" placeholder:"time"`
+	LeftFrom     time.Time     `help:"Start of the period replayed on the left, as long as --from to --to. Defaults to --from." name:"left-from" placeholder:"time"`
+	RightFrom    time.Time     `help:"Start of the period replayed on the right, as long as --from to --to. Defaults to --from." name:"right-from" placeholder:"time"`
+	FailOn       check.FailOn  `help:"Fail on alerts that only fire on the right (new-alerts), or on any difference (any-change)." name:"fail-on" enum:"none,new-alerts,any-change" default:"none" group:"Checks"`
+
+	Left        DatasourceFlags `embed:"" prefix:"left-" envprefix:"ALERTREPLAY_LEFT_" xorprefix:"left-" group:"Left datasource"`
+	Right       DatasourceFlags `embed:"" prefix:"right-" envprefix:"ALERTREPLAY_RIGHT_" xorprefix:"right-" group:"Right datasource"`
+	MatchFlags  `embed:""`
+	OutputFlags `embed:""`
+
+	// file2, alertName and alertName2 are resolved by Validate from the
+	// positional arguments, whose meaning depends on whether a single rules
+	// file is compared with itself.
+	file2      string
+	alertName  string
+	alertName2 string
 }
 
-func (cmd *DiffCmd) Run(g *Global) error {
-	ctx := context.Background()
+const (
+	// exprAlertName names the alerts of --expr-left and --expr-right.
+	exprAlertName = "Expr"
+	// periodTimeFormat names sides replayed over different periods.
+	periodTimeFormat = "2006-01-02 15:04"
+)
 
-	rule1, err := vmrule.ParseAlertRule(cmd.File1, cmd.AlertName)
-	if err != nil {
-		return fmt.Errorf("file1 (%s): parsing alert rule: %w", cmd.File1, err)
+func (cmd *DiffCmd) Validate() error {
+	if err := cmd.OutputFlags.Validate(); err != nil {
+		return err
 	}
 
-	rule2, err := vmrule.ParseAlertRule(cmd.File2, cmd.AlertName)
+	cmd.resolveArgs()
+
+	if !cmd.CompareRange.IsZero() && !cmd.LeftFrom.IsZero() {
+		return fmt.Errorf("pass either --compare-range or --left-from")
+	}
+
+	if cmd.comparesPeriods() && cmd.All {
+		return fmt.Errorf("--compare-range, --left-from and --right-from don't support --all")
+	}
+
+	if cmd.ExprLeft != "" || cmd.ExprRight != "" {
+		switch {
+		case cmd.ExprLeft == "" || cmd.ExprRight == "":
+			return fmt.Errorf("pass both --expr-left and --expr-right")
+		case cmd.File1 != "" || cmd.All:
+			return fmt.Errorf("--expr-left and --expr-right replace the rule files")
+		}
+
+		return nil
+	}
+
+	if cmd.ForLeft != 0 || cmd.ForRight != 0 {
+		return fmt.Errorf("--for-left and --for-right only apply to --expr-left and --expr-right")
+	}
+
+	if cmd.File1 == "" || cmd.file2 == "" {
+		return fmt.Errorf("pass two rule files, or --expr-left and --expr-right")
+	}
+
+	if cmd.All == (cmd.alertName != "") {
+		return fmt.Errorf("pass either an alert name or --all")
+	}
+
+	return nil
+}
+
+// resolveArgs sets the second rules file, which defaults to the first when
+// comparing datasources or periods, and the alert names to compare.
+func (cmd *DiffCmd) resolveArgs() {
+	cmd.file2, cmd.alertName = cmd.File2, cmd.AlertName
+
+	if cmd.singleFile() {
+		if cmd.AlertName == "" && !cmd.All {
+			// A single rules file replayed on both sides is followed by the
+			// alert name.
+			cmd.file2, cmd.alertName = "", cmd.File2
+		}
+
+		cmd.file2 = cmp.Or(cmd.file2, cmd.File1)
+	}
+
+	cmd.alertName2 = cmp.Or(cmd.AlertName2, cmd.alertName)
+}
+
+func (cmd *DiffCmd) Run(g *Global) error {
+	matcher, err := cmd.Matcher(g.To)
 	if err != nil {
-		return fmt.Errorf("file2 (%s): parsing alert rule: %w", cmd.File2, err)
+		return err
 	}
 
-	urlBuilder, err := g.DashboardURLBuilder()
+	if cmd.All {
+		return cmd.runAll(g, matcher)
+	}
+
+	ctx := context.Background()
+
+	left, right, err := cmd.sides(g)
 	if err != nil {
-		return fmt.Errorf("creating URL builder: %w", err)
+		return err
 	}
 
-	targets, err := g.Targets(ctx)
+	// Align the periods replayed on both sides.
+	matcher.Offset = right.from.Sub(left.from)
+	matcher.To = right.to
+
+	replayer, err := cmd.newReplayer(ctx, g)
 	if err != nil {
 		return err
 	}
 
 	var (
-		mu      sync.Mutex
-		alerts1 []alert.Alert
-		alerts2 []alert.Alert
+		alerts1, alerts2 []alert.Alert
+		series1, series2 []alert.Series
+		eg               errgroup.Group
 	)
 
-	client, err := prometheus.NewAPIClient(g.PrometheusURL, g.Parallelism)
+	eg.Go(func() error {
+		alerts, series, err := replayer.replay(ctx, prometheus.WithRecordingRules(replayer.left, left.recordings, left.from, left.to, g.Interval), left.rule, left.from, left.to)
+		if err != nil {
+			return fmt.Errorf("%s: %w", left.source, err)
+		}
+
+		alerts1, series1 = alerts, series
+
+		return nil
+	})
+
+	eg.Go(func() error {
+		alerts, series, err := replayer.replay(ctx, prometheus.WithRecordingRules(replayer.right, right.recordings, right.from, right.to, g.Interval), right.rule, right.from, right.to)
+		if err != nil {
+			return fmt.Errorf("%s: %w", right.source, err)
+		}
+
+		alerts2, series2 = alerts, series
+
+		return nil
+	})
+
+	if err := eg.Wait(); err != nil {
+		return err
+	}
+
+	result := output.DiffResult{
+		Run:         newRun(g, "diff", left.runRule(g, cmd.Left), right.runRule(g, cmd.Right)),
+		LeftName:    left.name,
+		RightName:   right.name,
+		LeftTo:      left.to,
+		RightTo:     right.to,
+		Rows:        alert.Diff(alerts1, alerts2, matcher),
+		LeftSeries:  series1,
+		RightSeries: series2,
+	}
+
+	if cmd.comparesPeriods() {
+		result.LabelSetChanges = alert.CompareLabelSets(alerts1, alerts2, matcher, left.to, right.to)
+		result.WithLabelSetChanges = true
+	}
+
+	if err := cmd.writeDiff(result); err != nil {
+		return err
+	}
+
+	return check.Result(check.Diff(cmd.FailOn, result.Rows))
+}
+
+// diffSide is the alert rule replayed on one side of a diff.
+type diffSide struct {
+	// name labels the side in the output.
+	name string
+	// source prefixes errors about the side.
+	source string
+	rule   rulefmt.Rule
+	// recordings are the recording rules evaluated alongside rule.
+	recordings []rulefmt.Rule
+	// from and to is the period the rule is replayed over.
+	from, to time.Time
+}
+
+// runRule describes the alert rule of the side replayed against d.
+func (s diffSide) runRule(g *Global, d DatasourceFlags) output.RunRule {
+	return output.RunRule{
+		Source:     s.name,
+		Datasource: d.url(g),
+		Tenant:     d.Tenant,
+		Alert:      s.rule.Alert,
+		Expr:       s.rule.Expr,
+		For:        time.Duration(s.rule.For),
+		From:       s.from,
+		To:         s.to,
+	}
+}
+
+// sides returns the alert rules to compare, either ad-hoc expressions or
+// alerts from the two files, and the periods to replay them over.
+func (cmd *DiffCmd) sides(g *Global) (diffSide, diffSide, error) {
+	left, right, err := cmd.rules(g)
 	if err != nil {
-		return fmt.Errorf("creating prometheus API client: %w", err)
+		return diffSide{}, diffSide{}, err
 	}
 
-	var eg errgroup.Group
-	for _, target := range targets {
-		eg.Go(func() error {
-			r := *rule1
+	length := g.To.Sub(g.From)
 
-			if target.Label != "" {
-				expr, err := prometheus.RewriteExpr(r.Expr, target)
-				if err != nil {
-					return fmt.Errorf("creating new expr for target %s: %w", target.AppendString(nil), err)
-				}
+	left.from = cmp.Or(cmd.LeftFrom, cmd.CompareRange, g.From)
+	left.to = left.from.Add(length)
+	right.from = cmp.Or(cmd.RightFrom, g.From)
+	right.to = right.from.Add(length)
 
-				r.Expr = expr
-			}
+	cmd.nameSides(g, &left, &right)
 
-			alerts, err := alert.Evaluate(ctx, client, r, g.From, g.To, g.Interval, urlBuilder)
-			if err != nil {
-				return fmt.Errorf("executing alert expr for file1 (%s): %w", cmd.File1, err)
-			}
+	return left, right, nil
+}
 
-			for _, label := range cmd.IgnoreLabels {
-				for i := range alerts {
-					delete(alerts[i].Labels, label)
-				}
-			}
+func (cmd *DiffCmd) rules(g *Global) (diffSide, diffSide, error) {
+	if cmd.ExprLeft != "" {
+		left := diffSide{
+			name:   "left",
+			source: "--expr-left",
+			rule:   rulefmt.Rule{Alert: exprAlertName, Expr: cmd.ExprLeft, For: model.Duration(cmd.ForLeft)},
+		}
+		right := diffSide{
+			name:   "right",
+			source: "--expr-right",
+			rule:   rulefmt.Rule{Alert: exprAlertName, Expr: cmd.ExprRight, For: model.Duration(cmd.ForRight)},
+		}
 
-			mu.Lock()
-			defer mu.Unlock()
-			alerts1 = append(alerts1, alerts...)
+		return left, right, nil
+	}
 
-			return nil
-		})
+	left, err := fileDiffSide(g, fmt.Sprintf("file1 (%s)", cmd.File1), cmd.File1, cmd.alertName)
+	if err != nil {
+		return diffSide{}, diffSide{}, err
+	}
+
+	right, err := fileDiffSide(g, fmt.Sprintf("file2 (%s)", cmd.file2), cmd.file2, cmd.alertName2)
+	if err != nil {
+		return diffSide{}, diffSide{}, err
+	}
+
+	if left.rule.Alert != right.rule.Alert {
+		// Replay both rules under the same name so that their alerts can
+		// match.
+		left.name, right.name = left.rule.Alert, right.rule.Alert
+		right.rule.Alert = left.rule.Alert
+	}
+
+	return left, right, nil
+}
+
+// comparesDatasources reports whether the sides of the diff are replayed
+// against different datasources.
+func (cmd *DiffCmd) comparesDatasources() bool {
+	return cmd.Left.IsSet() || cmd.Right.IsSet()
+}
+
+// comparesPeriods reports whether the sides of the diff are replayed over
+// different periods.
+func (cmd *DiffCmd) comparesPeriods() bool {
+	return !cmd.CompareRange.IsZero() || !cmd.LeftFrom.IsZero() || !cmd.RightFrom.IsZero()
+}
+
+// singleFile reports whether a single rules file can be compared with itself.
+func (cmd *DiffCmd) singleFile() bool {
+	return cmd.comparesDatasources() || cmd.comparesPeriods()
+}
+
+// nameSides names the sides after their datasources and periods when
+// comparing them, and left and right if that doesn't tell them apart.
+func (cmd *DiffCmd) nameSides(g *Global, left, right *diffSide) {
+	if cmd.comparesDatasources() {
+		left.name, right.name = cmd.Left.Name(g), cmd.Right.Name(g)
+	}
+
+	if cmd.comparesPeriods() {
+		left.name, right.name = periodName(left, cmd.comparesDatasources()), periodName(right, cmd.comparesDatasources())
+	}
+
+	if left.name == right.name {
+		left.name, right.name = "left", "right"
+	}
+}
+
+// periodName names a side after the start of its period, following the name
+// of its datasource if keepName is set.
+func periodName(side *diffSide, keepName bool) string {
+	name := side.from.UTC().Format(periodTimeFormat)
+	if keepName {
+		name = side.name + " " + name
+	}
+
+	return name
+}
+
+func fileDiffSide(g *Global, source, file, alertName string) (diffSide, error) {
+	r, err := vmrule.ParseAlertRule(file, alertName)
+	if err != nil {
+		return diffSide{}, fmt.Errorf("%s: parsing alert rule: %w", source, err)
+	}
+
+	recordings, err := g.RecordingRules(file)
+	if err != nil {
+		return diffSide{}, fmt.Errorf("%s: %w", source, err)
+	}
+
+	return diffSide{name: filepath.Base(file), source: source, rule: *r, recordings: recordings}, nil
+}
+
+// diffReplayer replays the alert rules of a diff for every target.
+type diffReplayer struct {
+	g            *Global
+	left         prometheus.Client
+	right        prometheus.Client
+	targets      []metricsql.LabelFilter
+	urlBuilder   dashboard.URLBuilder
+	ignoreLabels []string
+}
+
+func (cmd *DiffCmd) newReplayer(ctx context.Context, g *Global) (*diffReplayer, error) {
+	urlBuilder, err := g.DashboardURLBuilder()
+	if err != nil {
+		return nil, fmt.Errorf("creating URL builder: %w", err)
+	}
+
+	left, err := cmd.Left.Client(g)
+	if err != nil {
+		return nil, fmt.Errorf("creating left prometheus API client: %w", err)
+	}
+
+	targets, err := g.Targets(ctx, left)
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := cmd.Right.Client(g)
+	if err != nil {
+		return nil, fmt.Errorf("creating right prometheus API client: %w", err)
+	}
+
+	return &diffReplayer{
+		g:            g,
+		left:         left,
+		right:        right,
+		targets:      targets,
+		urlBuilder:   urlBuilder,
+		ignoreLabels: cmd.IgnoreLabels,
+	}, nil
+}
+
+// replay returns the alerts of rule across all targets replayed against
+// client from from to to, sorted and without the ignored labels, and the
+// values of its expression.
+func (d *diffReplayer) replay(ctx context.Context, client prometheus.Client, rule rulefmt.Rule, from, to time.Time) ([]alert.Alert, []alert.Series, error) {
+	var (
+		mu     sync.Mutex
+		alerts []alert.Alert
+		series []alert.Series
+		eg     errgroup.Group
+	)
 
+	for _, target := range d.targets {
 		eg.Go(func() error {
-			r := *rule2
+			r := rule
 
 			if target.Label != "" {
 				expr, err := prometheus.RewriteExpr(r.Expr, target)
@@ -99,76 +404,31 @@ func (cmd *DiffCmd) Run(g *Global) error {
 				r.Expr = expr
 			}
 
-			alerts, err := alert.Evaluate(ctx, client, r, g.From, g.To, g.Interval, urlBuilder)
+			result, err := alert.Evaluate(ctx, prometheus.WithFilters(client, target), r, from, to, d.g.Interval, d.urlBuilder, evaluator.Restarts{})
 			if err != nil {
-				return fmt.Errorf("executing alert expr for file2 (%s): %w", cmd.File2, err)
+				return fmt.Errorf("executing alert expr: %w", err)
 			}
 
-			for _, label := range cmd.IgnoreLabels {
-				for i := range alerts {
-					delete(alerts[i].Labels, label)
+			for _, label := range d.ignoreLabels {
+				for i := range result.Alerts {
+					delete(result.Alerts[i].Labels, label)
 				}
 			}
 
 			mu.Lock()
 			defer mu.Unlock()
-			alerts2 = append(alerts2, alerts...)
+			alerts = append(alerts, result.Alerts...)
+			series = append(series, result.Series...)
 
 			return nil
 		})
 	}
 
 	if err := eg.Wait(); err != nil {
-		return err
-	}
-
-	alert.Sort(alerts1)
-	alert.Sort(alerts2)
-
-	return printDiffResults(cmd.File1, cmd.File2, alerts1, alerts2)
-}
-
-func findMatchingAlert(ar alert.Alert, alerts []alert.Alert, used map[int]bool) int {
-	for i, candidate := range alerts {
-		if used[i] {
-			continue
-		}
-		if ar.Match(candidate) {
-			return i
-		}
-	}
-	return -1
-}
-
-func printDiffResults(file1, file2 string, alerts1, alerts2 []alert.Alert) error {
-	var (
-		baseFile1 = filepath.Base(file1)
-		baseFile2 = filepath.Base(file2)
-
-		alerts []alert.Alert
-		used2  = make(map[int]bool)
-	)
-
-	for _, ar := range alerts1 {
-		idx := findMatchingAlert(ar, alerts2, used2)
-
-		if idx == -1 {
-			ar.Source = baseFile1
-		} else {
-			used2[idx] = true
-		}
-
-		alerts = append(alerts, ar)
-	}
-
-	for i, ar := range alerts2 {
-		if !used2[i] {
-			ar.Source = baseFile2
-			alerts = append(alerts, ar)
-		}
+		return nil, nil, err
 	}
 
 	alert.Sort(alerts)
 
-	return output.PrintEvents(alerts)
+	return alerts, series, nil
 }
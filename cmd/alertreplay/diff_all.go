@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/steved/alertreplay/internal/alert"
+	"github.com/steved/alertreplay/internal/check"
+	"github.com/steved/alertreplay/internal/output"
+	"github.com/steved/alertreplay/internal/vmrule"
+)
+
+// runAll pairs the alert rules of both files by name and diffs each pair.
+func (cmd *DiffCmd) runAll(g *Global, matcher alert.Matcher) error {
+	ctx := context.Background()
+
+	rules1, err := parseDiffRules(cmd.File1)
+	if err != nil {
+		return fmt.Errorf("file1 (%s): %w", cmd.File1, err)
+	}
+
+	rules2, err := parseDiffRules(cmd.file2)
+	if err != nil {
+		return fmt.Errorf("file2 (%s): %w", cmd.file2, err)
+	}
+
+	names := slices.Sorted(maps.Keys(rules1))
+	for name := range rules2 {
+		if _, ok := rules1[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	replayer, err := cmd.newReplayer(ctx, g)
+	if err != nil {
+		return err
+	}
+
+	client1, err := g.WithRecordingRules(replayer.left, cmd.File1)
+	if err != nil {
+		return fmt.Errorf("file1 (%s): %w", cmd.File1, err)
+	}
+
+	client2, err := g.WithRecordingRules(replayer.right, cmd.file2)
+	if err != nil {
+		return fmt.Errorf("file2 (%s): %w", cmd.file2, err)
+	}
+
+	var (
+		summaries = make([]output.DiffSummary, len(names))
+		eg        errgroup.Group
+	)
+
+	for i, name := range names {
+		eg.Go(func() error {
+			rule1, ok1 := rules1[name]
+			rule2, ok2 := rules2[name]
+
+			var (
+				alerts1, alerts2 []alert.Alert
+				series1, series2 []alert.Series
+				err              error
+			)
+
+			if ok1 {
+				alerts1, series1, err = replayer.replay(ctx, client1, rule1, g.From, g.To)
+				if err != nil {
+					return fmt.Errorf("file1 (%s): alert %q: %w", cmd.File1, name, err)
+				}
+			}
+
+			if ok2 {
+				alerts2, series2, err = replayer.replay(ctx, client2, rule2, g.From, g.To)
+				if err != nil {
+					return fmt.Errorf("file2 (%s): alert %q: %w", cmd.file2, name, err)
+				}
+			}
+
+			summaries[i] = newDiffSummary(name, ok1, ok2, alerts1, alerts2, matcher, g.To)
+			summaries[i].LeftSeries, summaries[i].RightSeries = series1, series2
+
+			return nil
+		})
+	}
+
+	if err := eg.Wait(); err != nil {
+		return err
+	}
+
+	left := diffSide{name: filepath.Base(cmd.File1)}
+	right := diffSide{name: filepath.Base(cmd.file2)}
+	cmd.nameSides(g, &left, &right)
+
+	left.from, left.to, right.from, right.to = g.From, g.To, g.From, g.To
+
+	run := newRun(g, "diff")
+	for _, name := range names {
+		if r, ok := rules1[name]; ok {
+			left.rule = r
+			run.Rules = append(run.Rules, left.runRule(g, cmd.Left))
+		}
+		if r, ok := rules2[name]; ok {
+			right.rule = r
+			run.Rules = append(run.Rules, right.runRule(g, cmd.Right))
+		}
+	}
+
+	result := output.DiffResult{
+		Run:       run,
+		LeftName:  left.name,
+		RightName: right.name,
+		LeftTo:    g.To,
+		RightTo:   g.To,
+		Summaries: summaries,
+	}
+
+	if err := cmd.writeDiff(result); err != nil {
+		return err
+	}
+
+	return check.Result(check.Diff(cmd.FailOn, result.AllRows()))
+}
+
+// parseDiffRules returns the alert rules of file by name.
+func parseDiffRules(file string) (map[string]rulefmt.Rule, error) {
+	rules, err := vmrule.ParseAlertRules(file)
+	if err != nil {
+		return nil, fmt.Errorf("parsing alert rules: %w", err)
+	}
+
+	byName := make(map[string]rulefmt.Rule, len(rules))
+	for _, r := range rules {
+		if _, ok := byName[r.Alert]; ok {
+			return nil, fmt.Errorf("alert %q is defined more than once", r.Alert)
+		}
+
+		byName[r.Alert] = r
+	}
+
+	return byName, nil
+}
+
+// newDiffSummary matches the alerts of a rule replayed from both files with m.
+// in1 and in2 tell whether the rule exists in each file.
+func newDiffSummary(
+	name string,
+	in1, in2 bool,
+	alerts1, alerts2 []alert.Alert,
+	m alert.Matcher,
+	to time.Time,
+) output.DiffSummary {
+	summary := output.DiffSummary{
+		AlertName:    name,
+		Count1:       len(alerts1),
+		Count2:       len(alerts2),
+		Rows:         alert.Diff(alerts1, alerts2, m),
+		FiringChange: alert.FiringTime(alerts2, to) - alert.FiringTime(alerts1, to),
+	}
+
+	for _, row := range summary.Rows {
+		switch row.Kind {
+		case alert.DiffOnlyLeft:
+			summary.Only1++
+		case alert.DiffOnlyRight:
+			summary.Only2++
+		case alert.DiffMatched:
+			summary.Matched++
+		}
+	}
+
+	switch {
+	case !in1:
+		summary.Status = output.DiffAdded
+	case !in2:
+		summary.Status = output.DiffRemoved
+	case summary.Only1 > 0 || summary.Only2 > 0 || summary.FiringChange != 0:
+		summary.Status = output.DiffChanged
+	default:
+		summary.Status = output.DiffUnchanged
+	}
+
+	return summary
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/steved/alertreplay/internal/alert"
+	"github.com/steved/alertreplay/internal/output"
+)
+
+func TestNewDiffSummary(t *testing.T) {
+	var (
+		base = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		to   = base.Add(24 * time.Hour)
+		api  = map[string]string{"job": "api"}
+		db   = map[string]string{"job": "db"}
+	)
+
+	for _, tt := range []struct {
+		name     string
+		in1, in2 bool
+		alerts1  []alert.Alert
+		alerts2  []alert.Alert
+		want     output.DiffSummary
+	}{
+		{
+			name:    "unchanged",
+			in1:     true,
+			in2:     true,
+			alerts1: []alert.Alert{{OpenedAt: base, ResolvedAt: new(base.Add(time.Hour)), Labels: api}},
+			alerts2: []alert.Alert{{OpenedAt: base, ResolvedAt: new(base.Add(time.Hour)), Labels: api}},
+			want:    output.DiffSummary{Status: output.DiffUnchanged, Count1: 1, Count2: 1, Matched: 1},
+		},
+		{
+			name: "changed",
+			in1:  true,
+			in2:  true,
+			alerts1: []alert.Alert{
+				{OpenedAt: base, ResolvedAt: new(base.Add(time.Hour)), Labels: api},
+				{OpenedAt: base, ResolvedAt: new(base.Add(time.Hour)), Labels: db},
+			},
+			alerts2: []alert.Alert{{OpenedAt: base, Labels: api}},
+			want: output.DiffSummary{
+				Status: output.DiffChanged, Count1: 2, Count2: 1, Matched: 1, Only1: 1,
+				FiringChange: 22 * time.Hour,
+			},
+		},
+		{
+			name:    "added",
+			in2:     true,
+			alerts2: []alert.Alert{{OpenedAt: base, ResolvedAt: new(base.Add(time.Hour)), Labels: api}},
+			want:    output.DiffSummary{Status: output.DiffAdded, Count2: 1, Only2: 1, FiringChange: time.Hour},
+		},
+		{
+			name: "removed without alerts",
+			in1:  true,
+			want: output.DiffSummary{Status: output.DiffRemoved},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newDiffSummary("TargetDown", tt.in1, tt.in2, tt.alerts1, tt.alerts2, alert.DefaultMatcher, to)
+
+			assert.Len(t, got.Rows, tt.want.Matched+tt.want.Only1+tt.want.Only2)
+
+			got.Rows = nil
+			tt.want.AlertName = "TargetDown"
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -1,72 +1,238 @@
 package main
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/VictoriaMetrics/metricsql"
+	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/assert"
-
-	"github.com/steved/alertreplay/internal/alert"
+	"github.com/stretchr/testify/require"
 )
 
-func TestFindMatchingAlert(t *testing.T) {
-	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
-
+func TestDiffCmdValidate(t *testing.T) {
 	for _, tt := range []struct {
-		name   string
-		target alert.Alert
-		alerts []alert.Alert
-		used   map[int]bool
-		want   int
+		name    string
+		cmd     DiffCmd
+		wantErr string
 	}{
 		{
-			name:   "finds matching alert",
-			target: alert.Alert{OpenedAt: base, Labels: map[string]string{"job": "api"}},
-			alerts: []alert.Alert{
-				{OpenedAt: base, Labels: map[string]string{"job": "api"}},
-			},
-			used: map[int]bool{},
-			want: 0,
+			name: "alert name",
+			cmd:  DiffCmd{File1: "old.yaml", File2: "new.yaml", AlertName: "TargetDown"},
+		},
+		{
+			name: "two alert names",
+			cmd:  DiffCmd{File1: "old.yaml", File2: "new.yaml", AlertName: "TargetDown", AlertName2: "InstanceDown"},
+		},
+		{
+			name: "all",
+			cmd:  DiffCmd{File1: "old.yaml", File2: "new.yaml", All: true},
+		},
+		{
+			name:    "neither alert name nor all",
+			cmd:     DiffCmd{File1: "old.yaml", File2: "new.yaml"},
+			wantErr: "either an alert name or --all",
+		},
+		{
+			name:    "alert name and all",
+			cmd:     DiffCmd{File1: "old.yaml", File2: "new.yaml", AlertName: "TargetDown", All: true},
+			wantErr: "either an alert name or --all",
+		},
+		{
+			name:    "one file",
+			cmd:     DiffCmd{File1: "old.yaml"},
+			wantErr: "pass two rule files",
+		},
+		{
+			name: "one file against two datasources",
+			cmd:  DiffCmd{File1: "rules.yaml", File2: "TargetDown", Left: DatasourceFlags{URL: "http://prom:9090"}},
+		},
+		{
+			name: "all of one file against two datasources",
+			cmd:  DiffCmd{File1: "rules.yaml", All: true, Right: DatasourceFlags{Tenant: "team-a"}},
+		},
+		{
+			name: "one file over two periods",
+			cmd:  DiffCmd{File1: "rules.yaml", File2: "TargetDown", CompareRange: time.Now()},
+		},
+		{
+			name:    "compare range and left from",
+			cmd:     DiffCmd{File1: "rules.yaml", AlertName: "TargetDown", CompareRange: time.Now(), LeftFrom: time.Now()},
+			wantErr: "either --compare-range or --left-from",
 		},
 		{
-			name:   "skips already used alerts",
-			target: alert.Alert{OpenedAt: base, Labels: map[string]string{"job": "api"}},
-			alerts: []alert.Alert{
-				{OpenedAt: base, Labels: map[string]string{"job": "api"}},
-				{OpenedAt: base.Add(time.Minute), Labels: map[string]string{"job": "api"}},
-			},
-			used: map[int]bool{0: true},
-			want: 1,
+			name:    "all over two periods",
+			cmd:     DiffCmd{File1: "rules.yaml", All: true, RightFrom: time.Now()},
+			wantErr: "don't support --all",
 		},
 		{
-			name:   "returns -1 when no match",
-			target: alert.Alert{OpenedAt: base, Labels: map[string]string{"job": "api"}},
-			alerts: []alert.Alert{
-				{OpenedAt: base, Labels: map[string]string{"job": "server"}},
-			},
-			used: map[int]bool{},
-			want: -1,
+			name: "expressions",
+			cmd:  DiffCmd{ExprLeft: "up == 0", ExprRight: "up == 0", ForRight: 5 * time.Minute},
 		},
 		{
-			name:   "returns -1 for empty list",
-			target: alert.Alert{OpenedAt: base, Labels: map[string]string{"job": "api"}},
-			alerts: nil,
-			used:   map[int]bool{},
-			want:   -1,
+			name:    "one expression",
+			cmd:     DiffCmd{ExprLeft: "up == 0"},
+			wantErr: "pass both --expr-left and --expr-right",
 		},
 		{
-			name:   "returns -1 when all are used",
-			target: alert.Alert{OpenedAt: base, Labels: map[string]string{"job": "api"}},
-			alerts: []alert.Alert{
-				{OpenedAt: base, Labels: map[string]string{"job": "api"}},
-			},
-			used: map[int]bool{0: true},
-			want: -1,
+			name:    "expressions and files",
+			cmd:     DiffCmd{File1: "old.yaml", File2: "new.yaml", AlertName: "TargetDown", ExprLeft: "up == 0", ExprRight: "up == 0"},
+			wantErr: "replace the rule files",
+		},
+		{
+			name:    "for without expressions",
+			cmd:     DiffCmd{File1: "old.yaml", File2: "new.yaml", AlertName: "TargetDown", ForLeft: time.Minute},
+			wantErr: "only apply to --expr-left and --expr-right",
 		},
 	} {
 		t.Run(tt.name, func(t *testing.T) {
-			got := findMatchingAlert(tt.target, tt.alerts, tt.used)
-			assert.Equal(t, tt.want, got)
+			err := tt.cmd.Validate()
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
 		})
 	}
 }
+
+func TestDiffCmdValidate_singleFile(t *testing.T) {
+	cmd := DiffCmd{File1: "rules.yaml", File2: "TargetDown", Left: DatasourceFlags{URL: "http://prom:9090"}}
+	require.NoError(t, cmd.Validate())
+
+	assert.Equal(t, "rules.yaml", cmd.file2)
+	assert.Equal(t, "TargetDown", cmd.alertName)
+	assert.Equal(t, "TargetDown", cmd.alertName2)
+
+	// The parsed arguments are left as is, so validating again resolves them
+	// the same way.
+	assert.Equal(t, "TargetDown", cmd.File2)
+	assert.Empty(t, cmd.AlertName)
+	require.NoError(t, cmd.Validate())
+	assert.Equal(t, "rules.yaml", cmd.file2)
+	assert.Equal(t, "TargetDown", cmd.alertName)
+}
+
+func TestDiffCmdSides(t *testing.T) {
+	dir := t.TempDir()
+	file1 := filepath.Join(dir, "old.yaml")
+	file2 := filepath.Join(dir, "new.yaml")
+
+	require.NoError(t, os.WriteFile(file1, []byte(`
+spec:
+  groups:
+    - name: test
+      rules:
+        - alert: TargetDown
+          expr: up == 0
+`), 0o600))
+	require.NoError(t, os.WriteFile(file2, []byte(`
+spec:
+  groups:
+    - name: test
+      rules:
+        - alert: TargetDown
+          expr: up == 0
+          for: 5m
+        - alert: InstanceDown
+          expr: up{job="node"} == 0
+`), 0o600))
+
+	g := &Global{Interval: 30 * time.Second, PrometheusURL: "http://prom:9090"}
+
+	left, right, err := validated(t, DiffCmd{File1: file1, File2: file2, AlertName: "TargetDown"}).sides(g)
+	require.NoError(t, err)
+	assert.Equal(t, "old.yaml", left.name)
+	assert.Equal(t, "new.yaml", right.name)
+	assert.Equal(t, model.Duration(5*time.Minute), right.rule.For)
+
+	left, right, err = validated(t, DiffCmd{File1: file1, File2: file2, AlertName: "TargetDown", AlertName2: "InstanceDown"}).sides(g)
+	require.NoError(t, err)
+	assert.Equal(t, "TargetDown", left.name)
+	assert.Equal(t, "InstanceDown", right.name)
+	assert.Equal(t, "TargetDown", right.rule.Alert, "both sides are replayed under the same name")
+	assert.Equal(t, `up{job="node"} == 0`, right.rule.Expr)
+
+	_, _, err = validated(t, DiffCmd{File1: file1, File2: file2, AlertName: "InstanceDown"}).sides(g)
+	assert.ErrorContains(t, err, "file1 (")
+
+	left, right, err = validated(t, DiffCmd{
+		File1:     file2,
+		AlertName: "TargetDown",
+		Left:      DatasourceFlags{URL: "http://prom:9090"},
+		Right:     DatasourceFlags{URL: "http://vm:8428/select/0/prometheus"},
+	}).sides(g)
+	require.NoError(t, err)
+	assert.Equal(t, "prom:9090", left.name)
+	assert.Equal(t, "vm:8428", right.name)
+	assert.Equal(t, left.rule, right.rule)
+
+	lastWeek := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	thisWeek := lastWeek.Add(7 * 24 * time.Hour)
+	periods := &Global{Interval: 30 * time.Second, From: thisWeek, To: thisWeek.Add(24 * time.Hour)}
+
+	left, right, err = validated(t, DiffCmd{File1: file2, AlertName: "TargetDown", CompareRange: lastWeek}).sides(periods)
+	require.NoError(t, err)
+	assert.Equal(t, "2026-01-01 00:00", left.name)
+	assert.Equal(t, "2026-01-08 00:00", right.name)
+	assert.Equal(t, lastWeek, left.from)
+	assert.Equal(t, lastWeek.Add(24*time.Hour), left.to)
+	assert.Equal(t, thisWeek, right.from)
+	assert.Equal(t, thisWeek.Add(24*time.Hour), right.to)
+
+	left, right, err = validated(t, DiffCmd{ExprLeft: "up == 0", ExprRight: "up == 0", ForRight: 5 * time.Minute}).sides(g)
+	require.NoError(t, err)
+	assert.Equal(t, "left", left.name)
+	assert.Equal(t, "right", right.name)
+	assert.Equal(t, left.rule.Alert, right.rule.Alert)
+	assert.Equal(t, model.Duration(5*time.Minute), right.rule.For)
+}
+
+// validated returns cmd after validating it, which resolves its positional
+// arguments.
+func validated(t *testing.T, cmd DiffCmd) *DiffCmd {
+	t.Helper()
+	require.NoError(t, cmd.Validate())
+
+	return &cmd
+}
+
+func TestDatasourceFlagsName(t *testing.T) {
+	g := &Global{PrometheusURL: "http://prom:9090"}
+
+	assert.Equal(t, "prom:9090", DatasourceFlags{}.Name(g))
+	assert.Equal(t, "vm:8428/team-a", DatasourceFlags{URL: "https://vm:8428/prometheus", Tenant: "team-a"}.Name(g))
+}
+
+func TestDatasourceFlagsClient(t *testing.T) {
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[` +
+			`{"metric":{"job":"api"},"value":[0,"1"]},{"metric":{"job":"db"},"value":[0,"1"]}]}}`))
+	}))
+	defer server.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("secret\n"), 0o600))
+
+	g := &Global{PrometheusURL: "http://unused:9090", By: "job", To: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), Parallelism: 1}
+
+	client, err := DatasourceFlags{URL: server.URL, BearerTokenFile: tokenFile}.Client(g)
+	require.NoError(t, err)
+
+	// --by discovers its values through the given client, with its auth.
+	targets, err := g.Targets(t.Context(), client)
+	require.NoError(t, err)
+	assert.Equal(t, []metricsql.LabelFilter{{Label: "job", Value: "api"}, {Label: "job", Value: "db"}}, targets)
+	assert.Equal(t, "Bearer secret", authorization)
+
+	_, err = DatasourceFlags{PasswordFile: filepath.Join(t.TempDir(), "missing")}.Client(g)
+	assert.ErrorContains(t, err, "reading password")
+}
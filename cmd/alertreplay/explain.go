@@ -0,0 +1,53 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/steved/alertreplay/internal/explain"
+	"github.com/steved/alertreplay/internal/output"
+	"github.com/steved/alertreplay/internal/prometheus"
+	"github.com/steved/alertreplay/internal/vmrule"
+)
+
+type ExplainCmd struct {
+	AlertFile string    `arg:"" name:"alert-file" help:"Alert rules file (VMRule format)." required:""`
+	AlertName string    `arg:"" name:"alert-name" help:"Name of the alert to explain." required:""`
+	At        time.Time `help:"Time to evaluate the expression at. Defaults to --to." placeholder:"time"`
+}
+
+func (cmd *ExplainCmd) Run(g *Global) error {
+	ctx := context.Background()
+
+	if g.By != "" {
+		return fmt.Errorf("--by is not supported by explain, use --filters")
+	}
+
+	r, err := vmrule.ParseAlertRule(cmd.AlertFile, cmd.AlertName)
+	if err != nil {
+		return fmt.Errorf("parsing alert rule: %w", err)
+	}
+
+	if r.Expr, err = prometheus.RewriteExpr(r.Expr, g.Filters...); err != nil {
+		return fmt.Errorf("applying filters: %w", err)
+	}
+
+	client, err := prometheus.NewAPIClient(g.PrometheusURL, g.Parallelism)
+	if err != nil {
+		return fmt.Errorf("creating prometheus API client: %w", err)
+	}
+
+	evalClient, err := g.WithRecordingRules(client, cmd.AlertFile)
+	if err != nil {
+		return err
+	}
+
+	e, err := explain.Explain(ctx, prometheus.WithFilters(evalClient, g.Filters...), *r, cmp.Or(cmd.At, g.To), g.Interval)
+	if err != nil {
+		return fmt.Errorf("explaining alert expr: %w", err)
+	}
+
+	return output.PrintExplanation(e)
+}
@@ -9,25 +9,28 @@ import (
 
 	"github.com/VictoriaMetrics/metricsql"
 	"github.com/alecthomas/kong"
+	"github.com/prometheus/prometheus/model/rulefmt"
 	"github.com/rs/zerolog"
 	zlog "github.com/rs/zerolog/log"
 
 	"github.com/steved/alertreplay/internal/dashboard"
 	"github.com/steved/alertreplay/internal/prometheus"
 	"github.com/steved/alertreplay/internal/relativetime"
+	"github.com/steved/alertreplay/internal/vmrule"
 )
 
 type Global struct {
-	PrometheusURL string                  `help:"Prometheus API URL."`
-	From          time.Time               `help:"Start time: 'YYYY-MM-DD HH:MM:SS' or relative like '30 days ago'." required:"" placeholder:"time"`
-	To            time.Time               `help:"End time: 'YYYY-MM-DD HH:MM:SS' or relative like 'now'." default:"now" placeholder:"time"`
-	Interval      time.Duration           `help:"Query interval." default:"30s"`
-	Parallelism   int                     `help:"Number of parallel queries." default:"10"`
-	Filters       []metricsql.LabelFilter `help:"Append filters to alert expressions."`
-	By            string                  `help:"Discover filter values via Prometheus and run the alert once per value."`
-	DashboardURL  string                  `help:"Base URL for the dashboard UI." name:"ui-url"`
-	DashboardType dashboard.Type          `help:"Dashboard UI type: vmui, prometheus, or grafana." name:"ui-type" enum:"prometheus,vmui,grafana" default:"prometheus"`
-	Verbose       VerboseFlag             `help:"Enable debug logging." short:"v"`
+	PrometheusURL  string                  `help:"Prometheus API URL."`
+	From           time.Time               `help:"Start time: 'YYYY-MM-DD HH:MM:SS' or relative like '30 days ago'." required:"" placeholder:"time"`
+	To             time.Time               `help:"End time: 'YYYY-MM-DD HH:MM:SS' or relative like 'now'." default:"now" placeholder:"time"`
+	Interval       time.Duration           `help:"Query interval." default:"30s"`
+	Parallelism    int                     `help:"Number of parallel queries." default:"10"`
+	Filters        []metricsql.LabelFilter `help:"Append filters to alert expressions."`
+	By             string                  `help:"Discover filter values via Prometheus and run the alert once per value."`
+	DashboardURL   string                  `help:"Base URL for the dashboard UI." name:"ui-url"`
+	DashboardType  dashboard.Type          `help:"Dashboard UI type: vmui, prometheus, or grafana." name:"ui-type" enum:"prometheus,vmui,grafana" default:"prometheus"`
+	EvalRecordings bool                    `help:"Evaluate recording rules from the same file that the alert depends on." name:"recording-rules" default:"true" negatable:""`
+	Verbose        VerboseFlag             `help:"Enable debug logging." short:"v"`
 }
 
 type VerboseFlag bool
@@ -66,16 +69,42 @@ func (g *Global) DashboardURLBuilder() (dashboard.URLBuilder, error) {
 	return dashboard.New(g.DashboardType, g.DashboardURL)
 }
 
-func (g *Global) Targets(ctx context.Context) ([]metricsql.LabelFilter, error) {
-	var targets []metricsql.LabelFilter
+// WithRecordingRules wraps client so that series recorded by recording rules
+// from file are evaluated locally instead of read from the datasource.
+func (g *Global) WithRecordingRules(client prometheus.Client, file string) (prometheus.Client, error) {
+	recordings, err := g.RecordingRules(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return prometheus.WithRecordingRules(client, recordings, g.From, g.To, g.Interval), nil
+}
+
+// RecordingRules returns the recording rules from file to evaluate alongside
+// alert expressions, or none when that is disabled.
+func (g *Global) RecordingRules(file string) ([]rulefmt.Rule, error) {
+	if !g.EvalRecordings {
+		return nil, nil
+	}
+
+	recordings, err := vmrule.ParseRecordingRules(file)
+	if err != nil {
+		return nil, fmt.Errorf("parsing recording rules: %w", err)
+	}
+
+	return recordings, nil
+}
+
+// Targets returns the filters to run the alert with, one per value of --by
+// discovered through client, or --filters.
+func (g *Global) Targets(ctx context.Context, client prometheus.Client) ([]metricsql.LabelFilter, error) {
+	var (
+		targets []metricsql.LabelFilter
+		err     error
+	)
 
 	switch {
 	case g.By != "":
-		client, err := prometheus.NewAPIClient(g.PrometheusURL, g.Parallelism)
-		if err != nil {
-			return nil, fmt.Errorf("creating prometheus API client: %w", err)
-		}
-
 		targets, err = client.LabelValues(ctx, g.By, g.To)
 		if err != nil {
 			return nil, fmt.Errorf("discovering label values: %w", err)
@@ -102,9 +131,12 @@ func (g *Global) Targets(ctx context.Context) ([]metricsql.LabelFilter, error) {
 type CLI struct {
 	Global
 
-	Replay  ReplayCmd        `cmd:"" help:"Replay an alert rule against historical data." default:"withargs"`
-	Diff    DiffCmd          `cmd:"" help:"Compare an alert rule between two files."`
-	Version kong.VersionFlag `help:"Print version and exit."`
+	Replay      ReplayCmd        `cmd:"" help:"Replay an alert rule against historical data." default:"withargs"`
+	Diff        DiffCmd          `cmd:"" help:"Compare an alert rule between two files."`
+	ReplayGroup ReplayGroupCmd   `cmd:"" help:"Replay every rule of a group together, as Prometheus evaluates them." name:"replay-group"`
+	Verify      VerifyCmd        `cmd:"" help:"Compare a replayed alert with the ALERTS series it wrote in production."`
+	Explain     ExplainCmd       `cmd:"" help:"Show the values of an alert expression and its sub-expressions at a point in time."`
+	Version     kong.VersionFlag `help:"Print version and exit."`
 }
 
 func main() {
@@ -116,7 +148,7 @@ func main() {
 		kong.Name("alertreplay"),
 		kong.Description("Replay or compare alert rules against historical data."),
 		kong.UsageOnError(),
-		kong.Vars{"version": version},
+		kong.Vars{"version": version, "formats": formats()},
 		kong.TypeMapper(reflect.TypeFor[time.Time](), relativetime.Mapper),
 		kong.TypeMapper(reflect.TypeFor[metricsql.LabelFilter](), prometheus.LabelFilterMapper),
 	)
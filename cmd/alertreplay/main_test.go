@@ -1,10 +1,16 @@
 package main
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/prometheus/prometheus/model/rulefmt"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/steved/alertreplay/internal/prometheus"
 )
 
 func TestGlobalValidate(t *testing.T) {
@@ -105,3 +111,36 @@ func TestGlobalValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestGlobalWithRecordingRules(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`
+spec:
+  groups:
+    - name: test
+      rules:
+        - record: job:errors:rate5m
+          expr: sum(rate(errors_total[5m])) by (job)
+        - alert: HighErrorRate
+          expr: job:errors:rate5m > 0.05
+`), 0o600))
+
+	client, err := prometheus.NewAPIClient("http://localhost:9090", 1)
+	require.NoError(t, err)
+
+	g := Global{Interval: 30 * time.Second, EvalRecordings: true}
+
+	recordings, err := g.RecordingRules(file)
+	require.NoError(t, err)
+	assert.Equal(t, []rulefmt.Rule{{Record: "job:errors:rate5m", Expr: "sum(rate(errors_total[5m])) by (job)"}}, recordings)
+
+	wrapped, err := g.WithRecordingRules(client, file)
+	require.NoError(t, err)
+	assert.NotSame(t, client, wrapped)
+
+	g.EvalRecordings = false
+
+	wrapped, err = g.WithRecordingRules(client, file)
+	require.NoError(t, err)
+	assert.Same(t, client, wrapped)
+}
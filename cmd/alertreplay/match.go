@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/steved/alertreplay/internal/alert"
+)
+
+// MatchFlags configure how the commands that compare two sets of alerts pair
+// them up.
+type MatchFlags struct {
+	MatchMode      alert.MatchMode   `help:"How to match alerts with the same labels: 'opened' when they opened within --match-threshold, 'overlap' when their firing intervals overlap by at least --min-overlap." name:"match-mode" enum:"opened,overlap" default:"opened"`
+	MatchThreshold time.Duration     `help:"Maximum time between the openings of matching alerts in 'opened' mode." name:"match-threshold" default:"2m"`
+	MinOverlap     float64           `help:"Minimum percentage of their combined firing time during which matching alerts both fired in 'overlap' mode." name:"min-overlap" default:"50"`
+	LabelMap       map[string]string `help:"Rename labels before matching alerts, e.g. 'pod=kubernetes_pod'." name:"label-map" placeholder:"old=new"`
+	MatchOn        []string          `help:"Match alerts only on these labels, after renaming. Alerts keep all their labels in the output." name:"match-on" placeholder:"label"`
+}
+
+// Matcher returns the alert matcher configured by the flags. Unresolved
+// alerts fire until to.
+func (m *MatchFlags) Matcher(to time.Time) (alert.Matcher, error) {
+	if m.MatchThreshold < 0 {
+		return alert.Matcher{}, fmt.Errorf("--match-threshold must not be negative")
+	}
+
+	if m.MinOverlap <= 0 || m.MinOverlap > 100 {
+		return alert.Matcher{}, fmt.Errorf("--min-overlap must be between 0 and 100")
+	}
+
+	return alert.Matcher{
+		Mode:       m.MatchMode,
+		LabelMap:   m.LabelMap,
+		On:         m.MatchOn,
+		Threshold:  m.MatchThreshold,
+		MinOverlap: m.MinOverlap / 100,
+		To:         to,
+	}, nil
+}
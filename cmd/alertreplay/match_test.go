@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/steved/alertreplay/internal/alert"
+)
+
+func TestMatchFlags_Matcher(t *testing.T) {
+	to := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	m, err := (&MatchFlags{
+		MatchMode:      alert.MatchOverlap,
+		MatchThreshold: time.Minute,
+		MinOverlap:     80,
+		LabelMap:       map[string]string{"pod": "kubernetes_pod"},
+		MatchOn:        []string{"kubernetes_pod"},
+	}).Matcher(to)
+	require.NoError(t, err)
+	assert.Equal(t, alert.Matcher{
+		Mode:       alert.MatchOverlap,
+		LabelMap:   map[string]string{"pod": "kubernetes_pod"},
+		On:         []string{"kubernetes_pod"},
+		Threshold:  time.Minute,
+		MinOverlap: 0.8,
+		To:         to,
+	}, m)
+
+	_, err = (&MatchFlags{MatchMode: alert.MatchOverlap, MinOverlap: 120}).Matcher(to)
+	assert.ErrorContains(t, err, "--min-overlap")
+
+	_, err = (&MatchFlags{MatchMode: alert.MatchOpened, MatchThreshold: -time.Minute, MinOverlap: 50}).Matcher(to)
+	assert.ErrorContains(t, err, "--match-threshold")
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"golang.org/x/term"
+
+	"github.com/steved/alertreplay/internal/output"
+)
+
+// OutputFlags select the format replay and diff results are written in, and
+// where.
+type OutputFlags struct {
+	Output     output.Format `help:"Output format: ${formats}. auto is table in a terminal and markdown otherwise." short:"o" enum:"${formats}" default:"auto"`
+	OutputFile string        `help:"Write the output to this file instead of stdout." name:"output-file" type:"path" placeholder:"file"`
+}
+
+func (o *OutputFlags) Validate() error {
+	if o.Output == output.FormatTable && o.OutputFile != "" {
+		return fmt.Errorf("--output table can't be written to --output-file")
+	}
+
+	return nil
+}
+
+// writeReplay writes the result of a replay in the selected format.
+func (o *OutputFlags) writeReplay(r output.ReplayResult) error {
+	return o.write(func(w output.Writer, out io.Writer) error { return w.WriteReplay(out, r) })
+}
+
+// writeDiff writes the result of a diff in the selected format.
+func (o *OutputFlags) writeDiff(d output.DiffResult) error {
+	return o.write(func(w output.Writer, out io.Writer) error { return w.WriteDiff(out, d) })
+}
+
+// write calls fn with the writer of the selected format and --output-file, or
+// stdout. The file is only created once the format is known to be valid.
+func (o *OutputFlags) write(fn func(output.Writer, io.Writer) error) error {
+	terminal := o.OutputFile == "" && term.IsTerminal(int(os.Stdout.Fd()))
+
+	w, err := output.NewWriter(o.Output, terminal)
+	if err != nil {
+		return err
+	}
+
+	out := os.Stdout
+	if o.OutputFile != "" {
+		f, err := os.Create(o.OutputFile)
+		if err != nil {
+			return fmt.Errorf("creating output file: %w", err)
+		}
+
+		out = f
+	}
+
+	err = fn(w, out)
+
+	if out != os.Stdout {
+		if closeErr := out.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("writing output file: %w", closeErr)
+		}
+	}
+
+	return err
+}
+
+// formats lists the output formats for the --output flag.
+func formats() string {
+	names := make([]string, 0, len(output.Formats()))
+	for _, f := range output.Formats() {
+		names = append(names, string(f))
+	}
+
+	return strings.Join(names, ",")
+}
+
+// newRun describes a run of command over the range of the global flags.
+func newRun(g *Global, command string, rules ...output.RunRule) output.Run {
+	return output.Run{
+		Command:  command,
+		From:     g.From,
+		To:       g.To,
+		Interval: g.Interval,
+		Rules:    rules,
+	}
+}
+
+// runRule describes an alert rule replayed against --prometheus-url over the
+// range of the global flags.
+func runRule(g *Global, r rulefmt.Rule) output.RunRule {
+	return output.RunRule{
+		Datasource: g.PrometheusURL,
+		Alert:      r.Alert,
+		Expr:       r.Expr,
+		For:        time.Duration(r.For),
+		From:       g.From,
+		To:         g.To,
+	}
+}
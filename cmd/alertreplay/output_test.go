@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/steved/alertreplay/internal/alert"
+	"github.com/steved/alertreplay/internal/output"
+)
+
+func TestOutputFlagsValidate(t *testing.T) {
+	assert.NoError(t, (&OutputFlags{Output: output.FormatTable}).Validate())
+	assert.NoError(t, (&OutputFlags{Output: output.FormatJSON, OutputFile: "out.json"}).Validate())
+	assert.EqualError(t,
+		(&OutputFlags{Output: output.FormatTable, OutputFile: "out.txt"}).Validate(),
+		"--output table can't be written to --output-file",
+	)
+}
+
+func TestOutputFlags_writeReplay(t *testing.T) {
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	file := filepath.Join(t.TempDir(), "out.json")
+
+	flags := &OutputFlags{Output: output.FormatJSON, OutputFile: file}
+	require.NoError(t, flags.writeReplay(output.ReplayResult{
+		Run:    output.Run{Command: "replay", From: from, To: from.Add(time.Hour)},
+		Alerts: []alert.Alert{{OpenedAt: from, Labels: map[string]string{"alertname": "A"}}},
+	}))
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+
+	var doc struct {
+		Run    map[string]any   `json:"run"`
+		Alerts []map[string]any `json:"alerts"`
+	}
+	require.NoError(t, json.Unmarshal(data, &doc))
+	assert.Equal(t, "replay", doc.Run["command"])
+	require.Len(t, doc.Alerts, 1)
+	assert.Equal(t, "A", doc.Alerts[0]["alertname"])
+}
+
+func TestOutputFlags_writeReplay_unknownFormat(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "out.txt")
+	require.NoError(t, os.WriteFile(file, []byte("previous report"), 0o600))
+
+	flags := &OutputFlags{Output: "xml", OutputFile: file}
+	require.EqualError(t, flags.writeReplay(output.ReplayResult{}), `unknown output format "xml"`)
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "previous report", string(data), "the file is left untouched")
+}
+
+func TestFormats(t *testing.T) {
+	assert.Equal(t, "auto,csv,html,json,markdown,ndjson,table,tsv", formats())
+}
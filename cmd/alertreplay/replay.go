@@ -18,6 +18,11 @@ import (
 type ReplayCmd struct {
 	AlertFile string `arg:"" name:"alert-file" help:"Alert rules file (VMRule format)." required:""`
 	AlertName string `arg:"" name:"alert-name" help:"Name of the alert to replay." required:""`
+
+	ReportFlags  `embed:""`
+	RestartFlags `embed:""`
+	LimitFlags   `embed:""`
+	OutputFlags  `embed:""`
 }
 
 func (cmd *ReplayCmd) Run(g *Global) error {
@@ -34,24 +39,41 @@ func (cmd *ReplayCmd) Run(g *Global) error {
 		Dur("for", time.Duration(r.For)).
 		Msg("parsed alert rule")
 
+	report, err := cmd.Report(&cmd.OutputFlags)
+	if err != nil {
+		return err
+	}
+
 	urlBuilder, err := g.DashboardURLBuilder()
 	if err != nil {
 		return fmt.Errorf("creating URL builder: %w", err)
 	}
 
-	targets, err := g.Targets(ctx)
+	client, err := prometheus.NewAPIClient(g.PrometheusURL, g.Parallelism)
+	if err != nil {
+		return fmt.Errorf("creating prometheus API client: %w", err)
+	}
+
+	targets, err := g.Targets(ctx, client)
 	if err != nil {
 		return err
 	}
 
 	var (
-		mu        sync.Mutex
-		allAlerts []alert.Alert
+		mu            sync.Mutex
+		allAlerts     []alert.Alert
+		allNearMisses []alert.NearMiss
+		allSeries     []alert.Series
 	)
 
-	client, err := prometheus.NewAPIClient(g.PrometheusURL, g.Parallelism)
+	restarts, err := cmd.Restarts(ctx, client, g)
 	if err != nil {
-		return fmt.Errorf("creating prometheus API client: %w", err)
+		return err
+	}
+
+	evalClient, err := g.WithRecordingRules(client, cmd.AlertFile)
+	if err != nil {
+		return err
 	}
 
 	var eg errgroup.Group
@@ -67,13 +89,15 @@ func (cmd *ReplayCmd) Run(g *Global) error {
 				targetRule.Expr = expr
 			}
 
-			alerts, err := alert.Evaluate(ctx, client, targetRule, g.From, g.To, g.Interval, urlBuilder)
+			result, err := alert.Evaluate(ctx, prometheus.WithFilters(evalClient, target), targetRule, g.From, g.To, g.Interval, urlBuilder, restarts)
 			if err != nil {
 				return fmt.Errorf("executing alert expr: %w", err)
 			}
 
 			mu.Lock()
-			allAlerts = append(allAlerts, alerts...)
+			allAlerts = append(allAlerts, result.Alerts...)
+			allNearMisses = append(allNearMisses, result.NearMisses...)
+			allSeries = append(allSeries, result.Series...)
 			mu.Unlock()
 
 			return nil
@@ -84,7 +108,15 @@ func (cmd *ReplayCmd) Run(g *Global) error {
 		return err
 	}
 
-	alert.Sort(allAlerts)
+	err = report.Print(&cmd.OutputFlags, output.ReplayResult{
+		Run:        newRun(g, "replay", runRule(g, *r)),
+		Alerts:     allAlerts,
+		NearMisses: allNearMisses,
+		Series:     allSeries,
+	})
+	if err != nil {
+		return err
+	}
 
-	return output.PrintEvents(allAlerts)
+	return cmd.Check(allAlerts, g.From, g.To)
 }
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	zlog "github.com/rs/zerolog/log"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/steved/alertreplay/internal/alert"
+	"github.com/steved/alertreplay/internal/output"
+	"github.com/steved/alertreplay/internal/prometheus"
+	"github.com/steved/alertreplay/internal/vmrule"
+)
+
+type ReplayGroupCmd struct {
+	RuleFile  string `arg:"" name:"rule-file" help:"Alert rules file (VMRule format)." required:""`
+	GroupName string `arg:"" name:"group-name" help:"Name of the rule group to replay." required:""`
+
+	ReportFlags  `embed:""`
+	RestartFlags `embed:""`
+	LimitFlags   `embed:""`
+	OutputFlags  `embed:""`
+}
+
+func (cmd *ReplayGroupCmd) Run(g *Global) error {
+	ctx := context.Background()
+
+	group, err := vmrule.ParseRuleGroup(cmd.RuleFile, cmd.GroupName)
+	if err != nil {
+		return fmt.Errorf("parsing rule group: %w", err)
+	}
+
+	zlog.Debug().
+		Str("group", cmd.GroupName).
+		Int("rules", len(group)).
+		Msg("parsed rule group")
+
+	report, err := cmd.Report(&cmd.OutputFlags)
+	if err != nil {
+		return err
+	}
+
+	urlBuilder, err := g.DashboardURLBuilder()
+	if err != nil {
+		return fmt.Errorf("creating URL builder: %w", err)
+	}
+
+	client, err := prometheus.NewAPIClient(g.PrometheusURL, g.Parallelism)
+	if err != nil {
+		return fmt.Errorf("creating prometheus API client: %w", err)
+	}
+
+	targets, err := g.Targets(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	var (
+		mu            sync.Mutex
+		allAlerts     []alert.Alert
+		allNearMisses []alert.NearMiss
+	)
+
+	restarts, err := cmd.Restarts(ctx, client, g)
+	if err != nil {
+		return err
+	}
+
+	evalClient, err := g.WithRecordingRules(client, cmd.RuleFile)
+	if err != nil {
+		return err
+	}
+
+	var eg errgroup.Group
+	for _, target := range targets {
+		eg.Go(func() error {
+			targetGroup := slices.Clone(group)
+			if target.Label != "" {
+				for i := range targetGroup {
+					expr, err := prometheus.RewriteExpr(targetGroup[i].Expr, target)
+					if err != nil {
+						return fmt.Errorf("creating new expr for target %s: %w", target.AppendString(nil), err)
+					}
+
+					targetGroup[i].Expr = expr
+				}
+			}
+
+			result, err := alert.EvaluateGroup(ctx, prometheus.WithFilters(evalClient, target), targetGroup, g.From, g.To, g.Interval, urlBuilder, restarts)
+			if err != nil {
+				return fmt.Errorf("evaluating rule group: %w", err)
+			}
+
+			mu.Lock()
+			allAlerts = append(allAlerts, result.Alerts...)
+			allNearMisses = append(allNearMisses, result.NearMisses...)
+			mu.Unlock()
+
+			return nil
+		})
+	}
+
+	if err := eg.Wait(); err != nil {
+		return err
+	}
+
+	run := newRun(g, "replay-group")
+	for _, r := range group {
+		if r.Alert != "" {
+			run.Rules = append(run.Rules, runRule(g, r))
+		}
+	}
+
+	if err := report.Print(&cmd.OutputFlags, output.ReplayResult{Run: run, Alerts: allAlerts, NearMisses: allNearMisses}); err != nil {
+		return err
+	}
+
+	return cmd.Check(allAlerts, g.From, g.To)
+}
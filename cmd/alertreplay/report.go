@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/steved/alertreplay/internal/alert"
+	"github.com/steved/alertreplay/internal/alertmanager"
+	"github.com/steved/alertreplay/internal/output"
+)
+
+// ReportFlags control what is reported for replayed alerts by the replay
+// commands.
+type ReportFlags struct {
+	NearMisses         bool   `help:"Also report pending episodes that cleared before 'for' elapsed." name:"near-misses"`
+	AlertmanagerConfig string `help:"Simulate the notifications an Alertmanager with this configuration would send." name:"alertmanager-config" type:"existingfile" placeholder:"file"`
+	Silences           string `help:"Flag alerts matching silences from an amtool JSON export or a YAML list of matchers with start and end times." name:"silences" type:"existingfile" placeholder:"file"`
+}
+
+// Report prints replayed alerts as configured by ReportFlags.
+type Report struct {
+	flags    *ReportFlags
+	sim      *alertmanager.Simulator
+	silences []alertmanager.Silence
+}
+
+// Report loads the Alertmanager configuration and silences, if any, and
+// checks that out can write the requested reports, so that errors are
+// reported before replaying.
+func (r *ReportFlags) Report(out *OutputFlags) (*Report, error) {
+	if !output.WritesReports(out.Output) {
+		switch {
+		case r.NearMisses:
+			return nil, fmt.Errorf("--output %s can't write --near-misses", out.Output)
+		case r.AlertmanagerConfig != "":
+			return nil, fmt.Errorf("--output %s can't write the notifications of --alertmanager-config", out.Output)
+		}
+	}
+
+	report := &Report{flags: r}
+
+	if r.AlertmanagerConfig != "" {
+		sim, err := alertmanager.LoadFile(r.AlertmanagerConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		report.sim = sim
+	}
+
+	if r.Silences != "" {
+		silences, err := alertmanager.LoadSilences(r.Silences)
+		if err != nil {
+			return nil, fmt.Errorf("loading silences: %w", err)
+		}
+
+		report.silences = silences
+	}
+
+	return report, nil
+}
+
+// Print writes the alerts, near misses and series of a replay, and the
+// reports configured by ReportFlags, as selected by out.
+func (r *Report) Print(out *OutputFlags, result output.ReplayResult) error {
+	var (
+		alerts = result.Alerts
+		to     = result.Run.To
+	)
+
+	alert.Sort(alerts)
+
+	if r.sim != nil {
+		r.sim.Inhibit(alerts, to)
+	}
+
+	if r.silences != nil {
+		alertmanager.ApplySilences(alerts, r.silences, to)
+	}
+
+	result.WithNearMisses = r.flags.NearMisses
+	if r.flags.NearMisses {
+		alert.SortNearMisses(result.NearMisses)
+	} else {
+		result.NearMisses = nil
+	}
+
+	if r.sim != nil {
+		notifications, err := r.sim.Simulate(alerts, to)
+		if err != nil {
+			return fmt.Errorf("simulating alertmanager: %w", err)
+		}
+
+		result.Notifications, result.WithNotifications = notifications, true
+	}
+
+	return out.writeReplay(result)
+}
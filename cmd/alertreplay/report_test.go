@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/steved/alertreplay/internal/output"
+)
+
+func TestReportFlags_Report(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		flags   ReportFlags
+		format  output.Format
+		wantErr string
+	}{
+		{
+			name:   "near misses as json",
+			flags:  ReportFlags{NearMisses: true},
+			format: output.FormatJSON,
+		},
+		{
+			name:   "csv without reports",
+			format: output.FormatCSV,
+		},
+		{
+			name:    "near misses as csv",
+			flags:   ReportFlags{NearMisses: true},
+			format:  output.FormatCSV,
+			wantErr: "--output csv can't write --near-misses",
+		},
+		{
+			name:    "notifications as tsv",
+			flags:   ReportFlags{AlertmanagerConfig: "alertmanager.yml"},
+			format:  output.FormatTSV,
+			wantErr: "--output tsv can't write the notifications of --alertmanager-config",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.flags.Report(&OutputFlags{Output: tt.format})
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
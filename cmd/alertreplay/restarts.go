@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	zlog "github.com/rs/zerolog/log"
+
+	"github.com/steved/alertreplay/internal/evaluator"
+	"github.com/steved/alertreplay/internal/prometheus"
+)
+
+// RestartFlags configure the rule engine restarts simulated by the replay
+// commands.
+type RestartFlags struct {
+	RestartAt          []time.Time   `help:"Simulate rule engine restarts at these times." name:"restart-at" placeholder:"time"`
+	RestartsQuery      string        `help:"Simulate a restart whenever the value of this query changes, e.g. 'process_start_time_seconds{job=\"prometheus\"}'." name:"restarts-query" placeholder:"query"`
+	RestoreForState    bool          `help:"Restore 'for' state from ALERTS_FOR_STATE after restarts, as Prometheus does." name:"restore-for-state" default:"true" negatable:""`
+	ForGracePeriod     time.Duration `help:"Minimum time between a restart and a restored alert firing." name:"for-grace-period" default:"10m"`
+	ForOutageTolerance time.Duration `help:"Maximum restart downtime after which 'for' state is not restored." name:"for-outage-tolerance" default:"1h"`
+}
+
+// Restarts returns the restarts to simulate, detecting them with
+// --restarts-query if set.
+func (r *RestartFlags) Restarts(ctx context.Context, client prometheus.Client, g *Global) (evaluator.Restarts, error) {
+	times := slices.Clone(r.RestartAt)
+
+	if r.RestartsQuery != "" {
+		detected, err := prometheus.Restarts(ctx, client, r.RestartsQuery, g.From, g.To, g.Interval)
+		if err != nil {
+			return evaluator.Restarts{}, err
+		}
+
+		times = append(times, detected...)
+	}
+
+	if len(times) > 0 {
+		zlog.Info().Times("restarts", times).Msg("simulating rule engine restarts")
+	}
+
+	return evaluator.Restarts{
+		Times:           times,
+		Restore:         r.RestoreForState,
+		ForGracePeriod:  r.ForGracePeriod,
+		OutageTolerance: r.ForOutageTolerance,
+	}, nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	zlog "github.com/rs/zerolog/log"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/steved/alertreplay/internal/alert"
+	"github.com/steved/alertreplay/internal/output"
+	"github.com/steved/alertreplay/internal/prometheus"
+	"github.com/steved/alertreplay/internal/vmrule"
+)
+
+const (
+	sourceProduction = "production"
+	sourceReplay     = "replay"
+)
+
+type VerifyCmd struct {
+	AlertFile    string   `arg:"" name:"alert-file" help:"Alert rules file (VMRule format)." required:""`
+	AlertName    string   `arg:"" name:"alert-name" help:"Name of the alert to verify." required:""`
+	IgnoreLabels []string `help:"Labels to ignore when matching replayed alerts with ALERTS series, such as external labels." name:"ignore-labels"`
+
+	RestartFlags `embed:""`
+	MatchFlags   `embed:""`
+	OutputFlags  `embed:""`
+}
+
+func (cmd *VerifyCmd) Run(g *Global) error {
+	ctx := context.Background()
+
+	matcher, err := cmd.Matcher(g.To)
+	if err != nil {
+		return err
+	}
+
+	r, err := vmrule.ParseAlertRule(cmd.AlertFile, cmd.AlertName)
+	if err != nil {
+		return fmt.Errorf("parsing alert rule: %w", err)
+	}
+
+	urlBuilder, err := g.DashboardURLBuilder()
+	if err != nil {
+		return fmt.Errorf("creating URL builder: %w", err)
+	}
+
+	client, err := prometheus.NewAPIClient(g.PrometheusURL, g.Parallelism)
+	if err != nil {
+		return fmt.Errorf("creating prometheus API client: %w", err)
+	}
+
+	targets, err := g.Targets(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	restarts, err := cmd.Restarts(ctx, client, g)
+	if err != nil {
+		return err
+	}
+
+	evalClient, err := g.WithRecordingRules(client, cmd.AlertFile)
+	if err != nil {
+		return err
+	}
+
+	var (
+		mu         sync.Mutex
+		replayed   []alert.Alert
+		series     []alert.Series
+		production []alert.Alert
+	)
+
+	var eg errgroup.Group
+	for _, target := range targets {
+		eg.Go(func() error {
+			targetRule := *r
+			if target.Label != "" {
+				expr, err := prometheus.RewriteExpr(r.Expr, target)
+				if err != nil {
+					return fmt.Errorf("creating new expr for target %s: %w", target.AppendString(nil), err)
+				}
+
+				targetRule.Expr = expr
+			}
+
+			result, err := alert.Evaluate(ctx, prometheus.WithFilters(evalClient, target), targetRule, g.From, g.To, g.Interval, urlBuilder, restarts)
+			if err != nil {
+				return fmt.Errorf("executing alert expr: %w", err)
+			}
+
+			cmd.dropIgnoredLabels(result.Alerts)
+
+			mu.Lock()
+			defer mu.Unlock()
+			replayed = append(replayed, result.Alerts...)
+			series = append(series, result.Series...)
+
+			return nil
+		})
+
+		eg.Go(func() error {
+			query := alert.HistoryQuery(r.Alert)
+			if target.Label != "" {
+				expr, err := prometheus.RewriteExpr(query, target)
+				if err != nil {
+					return fmt.Errorf("creating new ALERTS query for target %s: %w", target.AppendString(nil), err)
+				}
+
+				query = expr
+			}
+
+			alerts, err := alert.History(ctx, client, query, g.From, g.To, g.Interval, urlBuilder)
+			if err != nil {
+				return fmt.Errorf("querying ALERTS: %w", err)
+			}
+
+			cmd.dropIgnoredLabels(alerts)
+
+			mu.Lock()
+			defer mu.Unlock()
+			production = append(production, alerts...)
+
+			return nil
+		})
+	}
+
+	if err := eg.Wait(); err != nil {
+		return err
+	}
+
+	alert.Sort(production)
+	alert.Sort(replayed)
+
+	rows := alert.Diff(production, replayed, matcher)
+
+	counts := make(map[alert.DiffKind]int)
+	for _, row := range rows {
+		counts[row.Kind]++
+	}
+
+	zlog.Info().
+		Int("matched", counts[alert.DiffMatched]).
+		Int("productionOnly", counts[alert.DiffOnlyLeft]).
+		Int("replayOnly", counts[alert.DiffOnlyRight]).
+		Msg("compared replay with ALERTS series")
+
+	return cmd.writeDiff(output.DiffResult{
+		Run:         newRun(g, "verify", runRule(g, *r)),
+		LeftName:    sourceProduction,
+		RightName:   sourceReplay,
+		LeftTo:      g.To,
+		RightTo:     g.To,
+		Rows:        rows,
+		RightSeries: series,
+	})
+}
+
+func (cmd *VerifyCmd) dropIgnoredLabels(alerts []alert.Alert) {
+	for _, label := range cmd.IgnoreLabels {
+		for i := range alerts {
+			delete(alerts[i].Labels, label)
+		}
+	}
+}
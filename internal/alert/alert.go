@@ -3,27 +3,126 @@ package alert
 import (
 	"fmt"
 	"maps"
-	"reflect"
 	"slices"
 	"strings"
 	"time"
 )
 
-const matchThreshold = 2 * time.Minute
-
 type Alert struct {
+	// PendingAt is when the alert condition first became true. It precedes
+	// OpenedAt by at least the rule's `for` duration.
+	PendingAt  time.Time
 	OpenedAt   time.Time
 	ResolvedAt *time.Time
-	Labels     map[string]string
-	URL        string
-	Source     string
+	// KeepFiringSince is when the expression stopped returning results while
+	// keep_firing_for held the alert firing until ResolvedAt.
+	KeepFiringSince *time.Time
+	Labels          map[string]string
+	Annotations     map[string]string
+	// Values is nil when the alert wasn't produced by an evaluation.
+	Values *Values
+	// Inhibited is set when an Alertmanager inhibit rule muted the alert
+	// while another alert fired.
+	Inhibited *Suppression
+	// Silenced is set when a silence matched the alert while it fired.
+	Silenced *Suppression
+	URL      string
+	Source   string
 }
 
-func (a Alert) Match(b Alert) bool {
-	if !reflect.DeepEqual(a.Labels, b.Labels) {
-		return false
+// FiringUntil returns when the alert resolved, or to if it didn't.
+func (a Alert) FiringUntil(to time.Time) time.Time {
+	if a.ResolvedAt != nil {
+		return *a.ResolvedAt
+	}
+	return to
+}
+
+// Shift returns a copy of the alert with its times moved by d.
+func (a Alert) Shift(d time.Duration) Alert {
+	if d == 0 {
+		return a
+	}
+
+	a.PendingAt = a.PendingAt.Add(d)
+	a.OpenedAt = a.OpenedAt.Add(d)
+	if a.ResolvedAt != nil {
+		a.ResolvedAt = new(a.ResolvedAt.Add(d))
+	}
+	if a.KeepFiringSince != nil {
+		a.KeepFiringSince = new(a.KeepFiringSince.Add(d))
+	}
+	return a
+}
+
+// FiringTime is the total time alerts fired, with unresolved alerts firing
+// until to.
+func FiringTime(alerts []Alert, to time.Time) time.Duration {
+	var total time.Duration
+	for _, ar := range alerts {
+		total += ar.FiringUntil(to).Sub(ar.OpenedAt)
+	}
+	return total
+}
+
+// FiringRatio is the share of the period from from to to during which at
+// least one of the alerts fired.
+func FiringRatio(alerts []Alert, from, to time.Time) float64 {
+	intervals := make([]Interval, 0, len(alerts))
+	for _, ar := range alerts {
+		intervals = append(intervals, Interval{Start: ar.OpenedAt, End: ar.FiringUntil(to)})
+	}
+
+	firing := NewSuppression(intervals, from, to)
+	if firing == nil {
+		return 0
+	}
+	return firing.Share
+}
+
+// Flaps is how many times alerts opened again with labels that already fired.
+func Flaps(alerts []Alert) int {
+	seen := make(map[string]bool, len(alerts))
+	for _, ar := range alerts {
+		seen[labelsKey(ar.Labels)] = true
+	}
+	return len(alerts) - len(seen)
+}
+
+// Values summarises the expression value while an alert was firing.
+type Values struct {
+	Firing float64
+	Peak   float64
+	Min    float64
+	Last   float64
+}
+
+// NearMiss is a pending episode whose condition cleared before the rule's
+// `for` duration elapsed, so it never fired.
+type NearMiss struct {
+	PendingAt time.Time
+	ClearedAt time.Time
+	For       time.Duration
+	Labels    map[string]string
+	Source    string
+}
+
+// Duration is how long the alert was pending.
+func (n NearMiss) Duration() time.Duration {
+	return n.ClearedAt.Sub(n.PendingAt)
+}
+
+// Closeness is the share of the `for` duration the alert spent pending.
+func (n NearMiss) Closeness() float64 {
+	if n.For <= 0 {
+		return 0
 	}
-	return a.OpenedAt.Sub(b.OpenedAt).Abs() <= matchThreshold
+	return float64(n.Duration()) / float64(n.For)
+}
+
+// Match reports whether b is the same alert as a according to DefaultMatcher.
+func (a Alert) Match(b Alert) bool {
+	return DefaultMatcher.Match(a, b)
 }
 
 func Sort(alerts []Alert) {
@@ -32,19 +131,41 @@ func Sort(alerts []Alert) {
 	})
 }
 
+func SortNearMisses(nearMisses []NearMiss) {
+	slices.SortFunc(nearMisses, func(l, r NearMiss) int {
+		return l.PendingAt.Compare(r.PendingAt)
+	})
+}
+
 func FormatLabels(labels map[string]string) string {
+	return "{" + strings.Join(formatPairs(labels, "__name__", "alertname"), ", ") + "}"
+}
+
+// FormatGroupLabels renders the labels of an Alertmanager group. Unlike
+// FormatLabels it keeps alertname, which groups commonly use.
+func FormatGroupLabels(labels map[string]string) string {
+	return "{" + strings.Join(formatPairs(labels), ", ") + "}"
+}
+
+// FormatAnnotations renders annotations on a single line with values quoted
+// so multi-line templates don't break tabular output.
+func FormatAnnotations(annotations map[string]string) string {
+	return strings.Join(formatPairs(annotations), ", ")
+}
+
+func formatPairs(m map[string]string, skip ...string) []string {
 	var (
-		keys  = slices.Sorted(maps.Keys(labels))
+		keys  = slices.Sorted(maps.Keys(m))
 		parts = make([]string, 0, len(keys))
 	)
 
 	for _, k := range keys {
-		if k == "__name__" || k == "alertname" {
+		if slices.Contains(skip, k) {
 			continue
 		}
 
-		parts = append(parts, fmt.Sprintf("%s=%q", k, labels[k]))
+		parts = append(parts, fmt.Sprintf("%s=%q", k, m[k]))
 	}
 
-	return "{" + strings.Join(parts, ", ") + "}"
+	return parts
 }
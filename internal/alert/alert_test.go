@@ -92,6 +92,54 @@ func TestSort(t *testing.T) {
 	assert.Equal(t, t3, alerts[2].OpenedAt)
 }
 
+func TestAlert_Shift(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	resolved := base.Add(time.Hour)
+
+	a := Alert{PendingAt: base.Add(-time.Minute), OpenedAt: base, ResolvedAt: &resolved}
+	shifted := a.Shift(24 * time.Hour)
+
+	assert.Equal(t, Alert{
+		PendingAt:  base.Add(24*time.Hour - time.Minute),
+		OpenedAt:   base.Add(24 * time.Hour),
+		ResolvedAt: new(resolved.Add(24 * time.Hour)),
+	}, shifted)
+	assert.Equal(t, base.Add(time.Hour), resolved, "the original alert is untouched")
+}
+
+func TestFiringTime(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 3*time.Hour, FiringTime([]Alert{
+		{OpenedAt: base, ResolvedAt: new(base.Add(time.Hour))},
+		{OpenedAt: base.Add(2 * time.Hour)},
+	}, base.Add(4*time.Hour)))
+	assert.Zero(t, FiringTime(nil, base))
+}
+
+func TestFiringRatio(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	api := map[string]string{"job": "api"}
+	db := map[string]string{"job": "db"}
+
+	alerts := []Alert{
+		{OpenedAt: base.Add(time.Hour), ResolvedAt: new(base.Add(3 * time.Hour)), Labels: api},
+		{OpenedAt: base.Add(2 * time.Hour), ResolvedAt: new(base.Add(4 * time.Hour)), Labels: db},
+		{OpenedAt: base.Add(8 * time.Hour), Labels: api},
+	}
+
+	assert.InDelta(t, 0.5, FiringRatio(alerts, base, base.Add(10*time.Hour)), 0.001)
+	assert.Zero(t, FiringRatio(nil, base, base.Add(time.Hour)))
+}
+
+func TestFlaps(t *testing.T) {
+	api := map[string]string{"job": "api"}
+	db := map[string]string{"job": "db"}
+
+	assert.Equal(t, 2, Flaps([]Alert{{Labels: api}, {Labels: db}, {Labels: api}, {Labels: api}}))
+	assert.Zero(t, Flaps([]Alert{{Labels: api}, {Labels: db}}))
+}
+
 func TestFormatLabels(t *testing.T) {
 	for _, tt := range []struct {
 		name   string
@@ -134,3 +182,42 @@ func TestFormatLabels(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatAnnotations(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		annotations map[string]string
+		want        string
+	}{
+		{
+			name:        "no annotations",
+			annotations: nil,
+			want:        "",
+		},
+		{
+			name:        "sorted by key",
+			annotations: map[string]string{"summary": "down", "runbook_url": "https://runbooks/down"},
+			want:        `runbook_url="https://runbooks/down", summary="down"`,
+		},
+		{
+			name:        "multi-line values stay on one line",
+			annotations: map[string]string{"description": "line one\nline two"},
+			want:        `description="line one\nline two"`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, FormatAnnotations(tt.annotations))
+		})
+	}
+}
+
+func TestNearMiss(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	nm := NearMiss{PendingAt: base, ClearedAt: base.Add(4 * time.Minute), For: 5 * time.Minute}
+	assert.Equal(t, 4*time.Minute, nm.Duration())
+	assert.InDelta(t, 0.8, nm.Closeness(), 1e-9)
+
+	nm.For = 0
+	assert.Zero(t, nm.Closeness())
+}
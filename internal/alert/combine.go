@@ -1,6 +1,8 @@
 package alert
 
 import (
+	"time"
+
 	"github.com/steved/alertreplay/internal/dashboard"
 	"github.com/steved/alertreplay/internal/evaluator"
 )
@@ -14,12 +16,21 @@ func CombineEvents(events []evaluator.Event, expr string, urlBuilder dashboard.U
 		switch event.Type {
 		case evaluator.EventOpened:
 			open[key] = &Alert{
-				OpenedAt: event.Time,
-				Labels:   event.Labels,
+				PendingAt:   event.ActiveAt,
+				OpenedAt:    event.Time,
+				Labels:      event.Labels,
+				Annotations: event.Annotations,
+			}
+
+			if event.Values != nil {
+				open[key].Values = new(Values(*event.Values))
 			}
 		case evaluator.EventResolved:
 			if alert, ok := open[key]; ok {
 				alert.ResolvedAt = new(event.Time)
+				if !event.KeepFiringSince.IsZero() {
+					alert.KeepFiringSince = new(event.KeepFiringSince)
+				}
 				result = append(result, *alert)
 				delete(open, key)
 			}
@@ -41,3 +52,24 @@ func CombineEvents(events []evaluator.Event, expr string, urlBuilder dashboard.U
 
 	return result
 }
+
+// NearMisses collects the pending episodes in events that cleared before
+// forDuration elapsed.
+func NearMisses(events []evaluator.Event, forDuration time.Duration) (result []NearMiss) {
+	for _, event := range events {
+		if event.Type != evaluator.EventNearMiss {
+			continue
+		}
+
+		result = append(result, NearMiss{
+			PendingAt: event.ActiveAt,
+			ClearedAt: event.Time,
+			For:       forDuration,
+			Labels:    event.Labels,
+		})
+	}
+
+	SortNearMisses(result)
+
+	return result
+}
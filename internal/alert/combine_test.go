@@ -36,6 +36,76 @@ func TestCombineEvents(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "resolved after keep_firing_for records when the hold started",
+			events: []evaluator.Event{
+				{Time: base, Labels: map[string]string{"job": "api"}, Type: evaluator.EventOpened},
+				{
+					Time:            base.Add(15 * time.Minute),
+					Labels:          map[string]string{"job": "api"},
+					Type:            evaluator.EventResolved,
+					KeepFiringSince: base.Add(5 * time.Minute),
+				},
+			},
+			want: []Alert{
+				{
+					OpenedAt:        base,
+					ResolvedAt:      new(base.Add(15 * time.Minute)),
+					KeepFiringSince: new(base.Add(5 * time.Minute)),
+					Labels:          map[string]string{"job": "api"},
+				},
+			},
+		},
+		{
+			name: "annotations are taken from the opened event",
+			events: []evaluator.Event{
+				{
+					Time:        base,
+					Labels:      map[string]string{"job": "api"},
+					Type:        evaluator.EventOpened,
+					Annotations: map[string]string{"summary": "api is down"},
+				},
+			},
+			want: []Alert{
+				{
+					OpenedAt:    base,
+					Labels:      map[string]string{"job": "api"},
+					Annotations: map[string]string{"summary": "api is down"},
+				},
+			},
+		},
+		{
+			name: "pending start is taken from the opened event",
+			events: []evaluator.Event{
+				{Time: base.Add(5 * time.Minute), ActiveAt: base, Labels: map[string]string{"job": "api"}, Type: evaluator.EventOpened},
+				{Time: base.Add(3 * time.Minute), ActiveAt: base.Add(time.Minute), Labels: map[string]string{"job": "api"}, Type: evaluator.EventNearMiss},
+			},
+			want: []Alert{
+				{
+					PendingAt: base,
+					OpenedAt:  base.Add(5 * time.Minute),
+					Labels:    map[string]string{"job": "api"},
+				},
+			},
+		},
+		{
+			name: "values are copied from the opened event",
+			events: []evaluator.Event{
+				{
+					Time:   base,
+					Labels: map[string]string{"job": "api"},
+					Type:   evaluator.EventOpened,
+					Values: &evaluator.Values{Firing: 2, Peak: 5, Min: 1, Last: 3},
+				},
+			},
+			want: []Alert{
+				{
+					OpenedAt: base,
+					Labels:   map[string]string{"job": "api"},
+					Values:   &Values{Firing: 2, Peak: 5, Min: 1, Last: 3},
+				},
+			},
+		},
 		{
 			name: "unresolved alert returned without ResolvedAt",
 			events: []evaluator.Event{
@@ -102,3 +172,30 @@ func TestCombineEvents(t *testing.T) {
 		})
 	}
 }
+
+func TestNearMisses(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	events := []evaluator.Event{
+		{Time: base.Add(20 * time.Minute), ActiveAt: base.Add(18 * time.Minute), Labels: map[string]string{"job": "late"}, Type: evaluator.EventNearMiss},
+		{Time: base.Add(10 * time.Minute), ActiveAt: base, Labels: map[string]string{"job": "api"}, Type: evaluator.EventOpened},
+		{Time: base.Add(4 * time.Minute), ActiveAt: base.Add(time.Minute), Labels: map[string]string{"job": "early"}, Type: evaluator.EventNearMiss},
+	}
+
+	assert.Equal(t, []NearMiss{
+		{
+			PendingAt: base.Add(time.Minute),
+			ClearedAt: base.Add(4 * time.Minute),
+			For:       10 * time.Minute,
+			Labels:    map[string]string{"job": "early"},
+		},
+		{
+			PendingAt: base.Add(18 * time.Minute),
+			ClearedAt: base.Add(20 * time.Minute),
+			For:       10 * time.Minute,
+			Labels:    map[string]string{"job": "late"},
+		},
+	}, NearMisses(events, 10*time.Minute))
+
+	assert.Nil(t, NearMisses(nil, 10*time.Minute))
+}
@@ -0,0 +1,105 @@
+package alert
+
+import (
+	"slices"
+	"time"
+)
+
+// DiffKind classifies a row of a diff between two sets of alerts.
+type DiffKind string
+
+const (
+	DiffMatched   DiffKind = "matched"
+	DiffOnlyLeft  DiffKind = "only-left"
+	DiffOnlyRight DiffKind = "only-right"
+)
+
+// DiffRow is a pair of matching alerts from both sides of a diff, or an alert
+// found on one side only.
+type DiffRow struct {
+	Kind  DiffKind
+	Left  *Alert
+	Right *Alert
+	// Offset is how much later the right alerts were replayed than the left
+	// ones.
+	Offset time.Duration
+}
+
+// Alert returns the left alert of the row, or the right one if there is none.
+func (r DiffRow) Alert() Alert {
+	if r.Left != nil {
+		return *r.Left
+	}
+
+	return *r.Right
+}
+
+// OpenedDelta is how much later the right alert opened than the left one,
+// less Offset. It is zero unless the row is matched.
+func (r DiffRow) OpenedDelta() time.Duration {
+	if r.Kind != DiffMatched {
+		return 0
+	}
+
+	return r.Right.OpenedAt.Sub(r.Left.OpenedAt) - r.Offset
+}
+
+// ResolvedDelta is how much later the right alert resolved than the left one,
+// less Offset. It reports false unless the row is matched and both alerts resolved, or
+// neither did.
+func (r DiffRow) ResolvedDelta() (time.Duration, bool) {
+	if r.Kind != DiffMatched {
+		return 0, false
+	}
+
+	switch {
+	case r.Left.ResolvedAt != nil && r.Right.ResolvedAt != nil:
+		return r.Right.ResolvedAt.Sub(*r.Left.ResolvedAt) - r.Offset, true
+	case r.Left.ResolvedAt == nil && r.Right.ResolvedAt == nil:
+		return 0, true
+	default:
+		return 0, false
+	}
+}
+
+// Diff pairs each left alert, in order, with the first unused right alert m
+// matches. Rows are sorted by the time their alert opened, with left alerts
+// moved by the matcher's Offset.
+func Diff(left, right []Alert, m Matcher) []DiffRow {
+	var (
+		rows  []DiffRow
+		index = newMatchIndex(right, m)
+	)
+
+	for i := range left {
+		idx := index.take(left[i])
+
+		if idx == -1 {
+			rows = append(rows, DiffRow{Kind: DiffOnlyLeft, Left: &left[i]})
+			continue
+		}
+
+		rows = append(rows, DiffRow{Kind: DiffMatched, Left: &left[i], Right: &right[idx], Offset: m.Offset})
+	}
+
+	for i := range right {
+		if !index.used[i] {
+			rows = append(rows, DiffRow{Kind: DiffOnlyRight, Right: &right[i]})
+		}
+	}
+
+	slices.SortStableFunc(rows, func(a, b DiffRow) int {
+		return a.openedAt(m.Offset).Compare(b.openedAt(m.Offset))
+	})
+
+	return rows
+}
+
+// openedAt is when the row's alert opened, with left alerts moved by offset.
+func (r DiffRow) openedAt(offset time.Duration) time.Time {
+	if r.Left != nil {
+		return r.Left.OpenedAt.Add(offset)
+	}
+
+	return r.Right.OpenedAt
+}
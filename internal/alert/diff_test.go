@@ -0,0 +1,85 @@
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	left := []Alert{
+		{OpenedAt: base, ResolvedAt: new(base.Add(time.Hour)), Labels: map[string]string{"job": "api"}},
+		{OpenedAt: base.Add(time.Hour), Labels: map[string]string{"job": "db"}},
+	}
+	right := []Alert{
+		{OpenedAt: base.Add(time.Minute), ResolvedAt: new(base.Add(100 * time.Minute)), Labels: map[string]string{"job": "api"}},
+		{OpenedAt: base.Add(2 * time.Hour), Labels: map[string]string{"job": "web"}},
+	}
+
+	rows := Diff(left, right, DefaultMatcher)
+	require.Len(t, rows, 3)
+
+	assert.Equal(t, DiffRow{Kind: DiffMatched, Left: &left[0], Right: &right[0]}, rows[0])
+	assert.Equal(t, DiffRow{Kind: DiffOnlyLeft, Left: &left[1]}, rows[1])
+	assert.Equal(t, DiffRow{Kind: DiffOnlyRight, Right: &right[1]}, rows[2])
+
+	assert.Equal(t, time.Minute, rows[0].OpenedDelta())
+	delta, ok := rows[0].ResolvedDelta()
+	assert.True(t, ok)
+	assert.Equal(t, 40*time.Minute, delta)
+
+	_, ok = rows[1].ResolvedDelta()
+	assert.False(t, ok)
+	assert.Equal(t, "web", rows[2].Alert().Labels["job"])
+}
+
+func TestDiff_offset(t *testing.T) {
+	var (
+		lastWeek = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		week     = 7 * 24 * time.Hour
+		thisWeek = lastWeek.Add(week)
+		api      = map[string]string{"job": "api"}
+	)
+
+	left := []Alert{
+		{OpenedAt: lastWeek, ResolvedAt: new(lastWeek.Add(time.Hour)), Labels: api},
+		{OpenedAt: lastWeek.Add(3 * time.Hour), Labels: api},
+	}
+	right := []Alert{
+		{OpenedAt: thisWeek.Add(2 * time.Hour), Labels: api},
+		{OpenedAt: thisWeek.Add(time.Minute), ResolvedAt: new(thisWeek.Add(30 * time.Minute)), Labels: api},
+	}
+
+	m := DefaultMatcher
+	m.Offset = week
+
+	rows := Diff(left, right, m)
+	require.Len(t, rows, 3)
+
+	assert.Equal(t, DiffRow{Kind: DiffMatched, Left: &left[0], Right: &right[1], Offset: week}, rows[0])
+	assert.Equal(t, time.Minute, rows[0].OpenedDelta())
+	delta, ok := rows[0].ResolvedDelta()
+	assert.True(t, ok)
+	assert.Equal(t, -30*time.Minute, delta)
+
+	assert.Equal(t, DiffRow{Kind: DiffOnlyRight, Right: &right[0]}, rows[1])
+	assert.Equal(t, DiffRow{Kind: DiffOnlyLeft, Left: &left[1]}, rows[2])
+}
+
+func TestDiffRow_ResolvedDelta(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	resolved := &Alert{OpenedAt: base, ResolvedAt: new(base.Add(time.Hour))}
+	unresolved := &Alert{OpenedAt: base}
+
+	delta, ok := DiffRow{Kind: DiffMatched, Left: unresolved, Right: unresolved}.ResolvedDelta()
+	assert.True(t, ok)
+	assert.Zero(t, delta)
+
+	_, ok = DiffRow{Kind: DiffMatched, Left: resolved, Right: unresolved}.ResolvedDelta()
+	assert.False(t, ok)
+}
@@ -0,0 +1,241 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/rules"
+	zlog "github.com/rs/zerolog/log"
+
+	"github.com/steved/alertreplay/internal/dashboard"
+	"github.com/steved/alertreplay/internal/evaluator"
+	"github.com/steved/alertreplay/internal/prometheus"
+)
+
+// alertsMetrics are the series alerting rules write as they are evaluated.
+var alertsMetrics = []string{"ALERTS", "ALERTS_FOR_STATE"}
+
+// groupRule is a rule evaluated as part of a group replay.
+type groupRule struct {
+	rule      rulefmt.Rule
+	alerting  *evaluator.Evaluator
+	recording *rules.RecordingRule
+	queryFn   rules.QueryFunc
+	// written holds the series the rule wrote at the previous evaluation, so
+	// they can be marked stale once they disappear.
+	written map[string]labels.Labels
+}
+
+// EvaluateGroup replays the rules of a group together. At each timestamp the
+// rules are evaluated in order, as Prometheus and vmalert do. Rules that read
+// ALERTS, ALERTS_FOR_STATE or the output of a recording rule that does are
+// evaluated locally against the series written by the group during the
+// replay. All other rules are queried through client. Restarts lose the state
+// of every alerting rule in the group.
+func EvaluateGroup(
+	ctx context.Context,
+	client prometheus.Client,
+	group []rulefmt.Rule,
+	from time.Time,
+	to time.Time,
+	interval time.Duration,
+	urlBuilder dashboard.URLBuilder,
+	restarts evaluator.Restarts,
+) (*Result, error) {
+	local, err := localRules(group)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		store        = prometheus.NewMemoryStorage()
+		localQueryFn = rules.EngineQueryFunc(prometheus.NewLocalEngine(interval), store)
+		groupRules   = make([]*groupRule, 0, len(group))
+	)
+
+	for i, r := range group {
+		var gr *groupRule
+
+		if local[i] {
+			zlog.Info().Str("rule", ruleName(r)).Msg("evaluating rule against series written by the group")
+
+			gr, err = newGroupRule(r, restarts, func(string) rules.QueryFunc { return localQueryFn })
+		} else {
+			gr, err = newRemoteGroupRule(ctx, client, r, from, to, interval, restarts)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", ruleName(r), err)
+		}
+
+		groupRules = append(groupRules, gr)
+	}
+
+	for _, ts := range prometheus.Timestamps(from, to, interval) {
+		for _, gr := range groupRules {
+			vector, err := gr.step(ctx, ts)
+			if err != nil {
+				return nil, fmt.Errorf("rule %q: %w", ruleName(gr.rule), err)
+			}
+
+			gr.written = store.Write(gr.written, ts.UnixMilli(), vector)
+		}
+	}
+
+	result := &Result{}
+	for _, gr := range groupRules {
+		if gr.alerting == nil {
+			continue
+		}
+
+		events := gr.alerting.Events()
+		result.Alerts = append(result.Alerts, CombineEvents(events, gr.rule.Expr, urlBuilder)...)
+		result.NearMisses = append(result.NearMisses, NearMisses(events, time.Duration(gr.rule.For))...)
+	}
+
+	Sort(result.Alerts)
+	SortNearMisses(result.NearMisses)
+
+	return result, nil
+}
+
+func newRemoteGroupRule(
+	ctx context.Context,
+	client prometheus.Client,
+	r rulefmt.Rule,
+	from time.Time,
+	to time.Time,
+	interval time.Duration,
+	restarts evaluator.Restarts,
+) (*groupRule, error) {
+	vectors, _, err := client.QueryExpr(ctx, r.Expr, from, to, interval)
+	if err != nil {
+		return nil, fmt.Errorf("executing queries: %w", err)
+	}
+
+	return newGroupRule(r, restarts, func(query string) rules.QueryFunc {
+		return prometheus.ExprQueryFunc(vectors, query, client)
+	})
+}
+
+// newGroupRule creates the evaluator for r. queryFn is given the expression
+// the rule passes to the query function.
+func newGroupRule(r rulefmt.Rule, restarts evaluator.Restarts, queryFn func(query string) rules.QueryFunc) (*groupRule, error) {
+	gr := &groupRule{rule: r}
+
+	if r.Alert != "" {
+		eval, err := evaluator.New(r)
+		if err != nil {
+			return nil, fmt.Errorf("creating rule evaluator: %w", err)
+		}
+
+		eval.SimulateRestarts(restarts)
+
+		gr.alerting = eval
+		gr.queryFn = queryFn(eval.Query())
+
+		return gr, nil
+	}
+
+	parsedExpr, err := parser.ParseExpr(r.Expr)
+	if err != nil {
+		return nil, fmt.Errorf("parsing expression: %w", err)
+	}
+
+	gr.recording = rules.NewRecordingRule(r.Record, parsedExpr, labels.FromMap(r.Labels))
+	gr.queryFn = queryFn(gr.recording.Query().String())
+
+	return gr, nil
+}
+
+func (gr *groupRule) step(ctx context.Context, ts time.Time) (promql.Vector, error) {
+	if gr.alerting != nil {
+		return gr.alerting.Step(ctx, gr.queryFn, ts)
+	}
+
+	vector, err := gr.recording.Eval(ctx, 0, ts, gr.queryFn, nil, 0)
+	if err != nil {
+		return nil, fmt.Errorf("evaluating at %s: %w", ts.Format(time.RFC3339), err)
+	}
+
+	return vector, nil
+}
+
+// localRules reports which rules in group must be evaluated against the
+// series the group writes: those reading ALERTS, ALERTS_FOR_STATE, or the
+// output of another such rule. These rules can only read series written by
+// the group.
+func localRules(group []rulefmt.Rule) ([]bool, error) {
+	var (
+		names    = make([][]string, len(group))
+		local    = make([]bool, len(group))
+		written  = make(map[string]bool)
+		localOut = make(map[string]bool)
+	)
+
+	for _, name := range alertsMetrics {
+		written[name] = true
+		localOut[name] = true
+	}
+
+	for i, r := range group {
+		metricNames, err := prometheus.MetricNames(r.Expr)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: parsing expression: %w", ruleName(r), err)
+		}
+
+		names[i] = metricNames
+
+		if r.Record != "" {
+			written[r.Record] = true
+		}
+	}
+
+	for changed := true; changed; {
+		changed = false
+
+		for i, r := range group {
+			if local[i] || !slices.ContainsFunc(names[i], func(name string) bool { return localOut[name] }) {
+				continue
+			}
+
+			local[i] = true
+			changed = true
+
+			if r.Record != "" {
+				localOut[r.Record] = true
+			}
+		}
+	}
+
+	for i, r := range group {
+		if !local[i] {
+			continue
+		}
+
+		for _, name := range names[i] {
+			if !written[name] {
+				return nil, fmt.Errorf(
+					"rule %q: reading ALERTS together with series not written by the group (%q) is not supported",
+					ruleName(r), name,
+				)
+			}
+		}
+	}
+
+	return local, nil
+}
+
+func ruleName(r rulefmt.Rule) string {
+	if r.Alert != "" {
+		return r.Alert
+	}
+
+	return r.Record
+}
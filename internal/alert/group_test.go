@@ -0,0 +1,134 @@
+package alert
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/steved/alertreplay/internal/evaluator"
+	"github.com/steved/alertreplay/internal/prometheus"
+)
+
+// fakeClient returns canned range query results per expression.
+type fakeClient struct {
+	prometheus.Client
+	results map[string]map[int64]promql.Vector
+	queried []string
+}
+
+func (f *fakeClient) QueryExpr(
+	_ context.Context,
+	expr string,
+	from time.Time,
+	to time.Time,
+	interval time.Duration,
+) (map[int64]promql.Vector, []time.Time, error) {
+	f.queried = append(f.queried, expr)
+	return f.results[expr], prometheus.Timestamps(from, to, interval), nil
+}
+
+func TestEvaluateGroup(t *testing.T) {
+	var (
+		base = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		step = time.Minute
+		at   = func(i int) time.Time { return base.Add(time.Duration(i) * step) }
+	)
+
+	down := func(job string, steps ...int) map[int64]promql.Vector {
+		vectors := make(map[int64]promql.Vector)
+		for _, i := range steps {
+			vectors[at(i).UnixMilli()] = promql.Vector{
+				{T: at(i).UnixMilli(), F: 0, Metric: labels.FromStrings("__name__", "up", "job", job)},
+			}
+		}
+		return vectors
+	}
+
+	// api is down for steps 1-4 and db for steps 2-3, so both fire during 2-3.
+	results := down("api", 1, 2, 3, 4)
+	for ts, vector := range down("db", 2, 3) {
+		results[ts] = append(results[ts], vector...)
+	}
+
+	client := &fakeClient{results: map[string]map[int64]promql.Vector{"up == 0": results}}
+
+	group := []rulefmt.Rule{
+		{Alert: "TargetDown", Expr: "up == 0"},
+		{Record: "alerts:firing:count", Expr: `count(ALERTS{alertstate="firing",alertname="TargetDown"})`},
+		{Alert: "ManyTargetsDown", Expr: "alerts:firing:count >= 2"},
+	}
+
+	result, err := EvaluateGroup(t.Context(), client, group, at(0), at(6), step, nil, evaluator.Restarts{})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"up == 0"}, client.queried, "only rules not reading ALERTS are queried")
+
+	type episode struct {
+		alertname, job   string
+		opened, resolved time.Time
+	}
+
+	var got []episode
+	for _, a := range result.Alerts {
+		require.NotNil(t, a.ResolvedAt)
+		got = append(got, episode{a.Labels["alertname"], a.Labels["job"], a.OpenedAt, *a.ResolvedAt})
+	}
+
+	assert.ElementsMatch(t, []episode{
+		{"TargetDown", "api", at(1), at(5)},
+		{"TargetDown", "db", at(2), at(4)},
+		{"ManyTargetsDown", "", at(2), at(4)},
+	}, got)
+}
+
+func TestEvaluateGroup_subquery(t *testing.T) {
+	var (
+		base = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		step = time.Minute
+		at   = func(i int) time.Time { return base.Add(time.Duration(i) * step) }
+	)
+
+	results := make(map[int64]promql.Vector)
+	for i := 1; i <= 2; i++ {
+		results[at(i).UnixMilli()] = promql.Vector{
+			{T: at(i).UnixMilli(), F: 0, Metric: labels.FromStrings("__name__", "up", "job", "api")},
+		}
+	}
+
+	client := &fakeClient{results: map[string]map[int64]promql.Vector{"up == 0": results}}
+
+	group := []rulefmt.Rule{
+		{Alert: "TargetDown", Expr: "up == 0"},
+		{Alert: "TargetDownRecently", Expr: `max_over_time(count(ALERTS{alertstate="firing",alertname="TargetDown"})[5m:]) > 0`},
+	}
+
+	result, err := EvaluateGroup(t.Context(), client, group, at(0), at(10), step, nil, evaluator.Restarts{})
+	require.NoError(t, err)
+
+	recently := slices.IndexFunc(result.Alerts, func(a Alert) bool { return a.Labels["alertname"] == "TargetDownRecently" })
+	require.NotEqual(t, -1, recently)
+
+	a := result.Alerts[recently]
+	assert.Equal(t, at(1), a.OpenedAt)
+	require.NotNil(t, a.ResolvedAt)
+	assert.Equal(t, at(7), *a.ResolvedAt, "the subquery steps every evaluation interval over the last 5m")
+}
+
+func TestEvaluateGroup_mixedSeries(t *testing.T) {
+	group := []rulefmt.Rule{
+		{Alert: "TargetDown", Expr: "up == 0"},
+		{Alert: "DownAndBusy", Expr: `ALERTS{alertname="TargetDown"} and on(job) rate(requests_total[5m]) > 1`},
+	}
+
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	_, err := EvaluateGroup(t.Context(), &fakeClient{}, group, base, base.Add(time.Hour), time.Minute, nil, evaluator.Restarts{})
+	assert.ErrorContains(t, err, `series not written by the group ("requests_total")`)
+}
@@ -0,0 +1,81 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/steved/alertreplay/internal/dashboard"
+	"github.com/steved/alertreplay/internal/prometheus"
+)
+
+// HistoryQuery returns the selector of the firing ALERTS series written for
+// an alerting rule.
+func HistoryQuery(alertName string) string {
+	return fmt.Sprintf(`ALERTS{alertname=%q, alertstate="firing"}`, alertName)
+}
+
+// History returns the alerts recorded in the ALERTS series selected by query,
+// as written by Prometheus or vmalert while evaluating the rule. An alert
+// opens at the first step its series is present and resolves at the first
+// step it is absent.
+func History(
+	ctx context.Context,
+	client prometheus.Client,
+	query string,
+	from time.Time,
+	to time.Time,
+	interval time.Duration,
+	urlBuilder dashboard.URLBuilder,
+) ([]Alert, error) {
+	vectors, timestamps, err := client.QueryExpr(ctx, query, from, to, interval)
+	if err != nil {
+		return nil, fmt.Errorf("executing queries: %w", err)
+	}
+
+	var (
+		result []Alert
+		open   = make(map[string]*Alert)
+	)
+
+	for _, ts := range timestamps {
+		present := make(map[string]struct{})
+
+		for _, sample := range vectors[ts.UnixMilli()] {
+			lset := labels.NewBuilder(sample.Metric).Del(labels.MetricName, "alertstate").Labels()
+			key := lset.String()
+
+			present[key] = struct{}{}
+			if _, ok := open[key]; !ok {
+				open[key] = &Alert{OpenedAt: ts, Labels: lset.Map()}
+			}
+		}
+
+		for key, alert := range open {
+			if _, ok := present[key]; ok {
+				continue
+			}
+
+			alert.ResolvedAt = new(ts)
+			result = append(result, *alert)
+			delete(open, key)
+		}
+	}
+
+	for _, alert := range open {
+		result = append(result, *alert)
+	}
+
+	Sort(result)
+
+	if urlBuilder != nil {
+		for i := range result {
+			alert := &result[i]
+			alert.URL = urlBuilder.BuildURL(query, alert.OpenedAt, alert.ResolvedAt)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,50 @@
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryQuery(t *testing.T) {
+	assert.Equal(t, `ALERTS{alertname="TargetDown", alertstate="firing"}`, HistoryQuery("TargetDown"))
+}
+
+func TestHistory(t *testing.T) {
+	var (
+		base  = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		step  = time.Minute
+		at    = func(i int) time.Time { return base.Add(time.Duration(i) * step) }
+		query = HistoryQuery("TargetDown")
+	)
+
+	series := func(job string) labels.Labels {
+		return labels.FromStrings("__name__", "ALERTS", "alertname", "TargetDown", "alertstate", "firing", "job", job)
+	}
+
+	vectors := make(map[int64]promql.Vector)
+	add := func(job string, steps ...int) {
+		for _, i := range steps {
+			vectors[at(i).UnixMilli()] = append(vectors[at(i).UnixMilli()], promql.Sample{T: at(i).UnixMilli(), Metric: series(job), F: 1})
+		}
+	}
+
+	// api fires twice, db fires until the end of the range.
+	add("api", 1, 2, 5)
+	add("db", 3, 4, 5, 6)
+
+	client := &fakeClient{results: map[string]map[int64]promql.Vector{query: vectors}}
+
+	alerts, err := History(t.Context(), client, query, at(0), at(6), step, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []Alert{
+		{OpenedAt: at(1), ResolvedAt: new(at(3)), Labels: map[string]string{"alertname": "TargetDown", "job": "api"}},
+		{OpenedAt: at(3), Labels: map[string]string{"alertname": "TargetDown", "job": "db"}},
+		{OpenedAt: at(5), ResolvedAt: new(at(6)), Labels: map[string]string{"alertname": "TargetDown", "job": "api"}},
+	}, alerts)
+}
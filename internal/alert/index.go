@@ -0,0 +1,124 @@
+package alert
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// windowSlack widens the overlap search window to absorb float rounding.
+const windowSlack = time.Millisecond
+
+// matchIndex finds the alerts a Matcher matches without comparing every pair.
+// Alerts are bucketed by the labels the matcher compares, and each bucket is
+// sorted by opening time so only the alerts that opened within the window a
+// match requires are compared.
+type matchIndex struct {
+	m       Matcher
+	alerts  []Alert
+	buckets map[string][]int
+	used    []bool
+}
+
+func newMatchIndex(alerts []Alert, m Matcher) *matchIndex {
+	buckets := make(map[string][]int)
+	for i, ar := range alerts {
+		key := labelsKey(m.MatchLabels(ar.Labels))
+		buckets[key] = append(buckets[key], i)
+	}
+
+	for _, bucket := range buckets {
+		slices.SortStableFunc(bucket, func(i, j int) int {
+			return alerts[i].OpenedAt.Compare(alerts[j].OpenedAt)
+		})
+	}
+
+	return &matchIndex{
+		m:       m,
+		alerts:  alerts,
+		buckets: buckets,
+		used:    make([]bool, len(alerts)),
+	}
+}
+
+// take marks the first unused alert, in the order alerts were indexed, that
+// matches ar as used and returns its index, or -1 if there is none.
+func (x *matchIndex) take(ar Alert) int {
+	bucket := x.buckets[labelsKey(x.m.MatchLabels(ar.Labels))]
+	ar = ar.Shift(x.m.Offset)
+
+	from, to, bounded := x.m.openedWindow(ar)
+
+	start := 0
+	if bounded {
+		start, _ = slices.BinarySearchFunc(bucket, from, func(i int, t time.Time) int {
+			return x.alerts[i].OpenedAt.Compare(t)
+		})
+	}
+
+	found := -1
+	for _, i := range bucket[start:] {
+		if bounded && x.alerts[i].OpenedAt.After(to) {
+			break
+		}
+
+		if x.used[i] || (found != -1 && i > found) {
+			continue
+		}
+
+		if x.m.matchTimes(ar, x.alerts[i]) {
+			found = i
+		}
+	}
+
+	if found != -1 {
+		x.used[found] = true
+	}
+
+	return found
+}
+
+// openedWindow returns the range an alert must have opened in to match ar. It
+// reports false if the range is unbounded.
+func (m Matcher) openedWindow(ar Alert) (time.Time, time.Time, bool) {
+	if m.Mode != MatchOverlap {
+		return ar.OpenedAt.Add(-m.Threshold), ar.OpenedAt.Add(m.Threshold), true
+	}
+
+	// An alert that opened before ar overlaps at most ar's firing time out of
+	// a combined firing time that grows with how much earlier it opened.
+	end := ar.FiringUntil(m.To)
+	firing := end.Sub(ar.OpenedAt)
+	if m.MinOverlap <= 0 || firing < 0 {
+		return time.Time{}, time.Time{}, false
+	}
+
+	earliest := float64(firing) * (1 - m.MinOverlap) / m.MinOverlap
+	if earliest > math.MaxInt64/2 {
+		return time.Time{}, time.Time{}, false
+	}
+
+	return ar.OpenedAt.Add(-time.Duration(earliest) - windowSlack), end, true
+}
+
+// labelsKey identifies a label set, for Match and the buckets of matchIndex
+// to agree on which alerts have the same labels. It tells a nil label set
+// apart from an empty one.
+func labelsKey(labels map[string]string) string {
+	if labels == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for _, name := range slices.Sorted(maps.Keys(labels)) {
+		b.WriteString(name)
+		b.WriteByte(0xff)
+		b.WriteString(labels[name])
+		b.WriteByte(0xff)
+	}
+
+	return b.String()
+}
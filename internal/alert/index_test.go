@@ -0,0 +1,179 @@
+package alert
+
+import (
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchIndex_take(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, tt := range []struct {
+		name   string
+		target Alert
+		alerts []Alert
+		used   map[int]bool
+		want   int
+	}{
+		{
+			name:   "finds matching alert",
+			target: Alert{OpenedAt: base, Labels: map[string]string{"job": "api"}},
+			alerts: []Alert{
+				{OpenedAt: base, Labels: map[string]string{"job": "api"}},
+			},
+			used: map[int]bool{},
+			want: 0,
+		},
+		{
+			name:   "skips already used alerts",
+			target: Alert{OpenedAt: base, Labels: map[string]string{"job": "api"}},
+			alerts: []Alert{
+				{OpenedAt: base, Labels: map[string]string{"job": "api"}},
+				{OpenedAt: base.Add(time.Minute), Labels: map[string]string{"job": "api"}},
+			},
+			used: map[int]bool{0: true},
+			want: 1,
+		},
+		{
+			name:   "returns -1 when no match",
+			target: Alert{OpenedAt: base, Labels: map[string]string{"job": "api"}},
+			alerts: []Alert{
+				{OpenedAt: base, Labels: map[string]string{"job": "server"}},
+			},
+			used: map[int]bool{},
+			want: -1,
+		},
+		{
+			name:   "returns -1 for empty list",
+			target: Alert{OpenedAt: base, Labels: map[string]string{"job": "api"}},
+			alerts: nil,
+			used:   map[int]bool{},
+			want:   -1,
+		},
+		{
+			name:   "returns -1 when all are used",
+			target: Alert{OpenedAt: base, Labels: map[string]string{"job": "api"}},
+			alerts: []Alert{
+				{OpenedAt: base, Labels: map[string]string{"job": "api"}},
+			},
+			used: map[int]bool{0: true},
+			want: -1,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			index := newMatchIndex(tt.alerts, DefaultMatcher)
+			for i := range tt.used {
+				index.used[i] = true
+			}
+
+			got := index.take(tt.target)
+			assert.Equal(t, tt.want, got)
+			if got != -1 {
+				assert.True(t, index.used[got])
+			}
+		})
+	}
+}
+
+func TestMatchIndex_takeFirstByIndex(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	api := map[string]string{"job": "api"}
+
+	index := newMatchIndex([]Alert{
+		{OpenedAt: base.Add(time.Minute), Labels: api},
+		{OpenedAt: base.Add(-time.Minute), Labels: api},
+	}, DefaultMatcher)
+
+	assert.Equal(t, 0, index.take(Alert{OpenedAt: base, Labels: api}))
+	assert.Equal(t, 1, index.take(Alert{OpenedAt: base, Labels: api}))
+	assert.Equal(t, -1, index.take(Alert{OpenedAt: base, Labels: api}))
+}
+
+// TestDiff_pairwise checks that the index matches the same alerts as
+// comparing every pair of alerts.
+func TestDiff_pairwise(t *testing.T) {
+	var (
+		base = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		to   = base.Add(12 * time.Hour)
+		rng  = rand.New(rand.NewPCG(1, 2))
+	)
+
+	randomAlerts := func(n int) []Alert {
+		alerts := make([]Alert, n)
+		for i := range alerts {
+			opened := base.Add(time.Duration(rng.IntN(12*60)) * time.Minute)
+			alerts[i] = Alert{
+				OpenedAt: opened,
+				Labels:   map[string]string{"job": strconv.Itoa(rng.IntN(3)), "pod": strconv.Itoa(rng.IntN(2))},
+			}
+			if rng.IntN(4) > 0 {
+				alerts[i].ResolvedAt = new(opened.Add(time.Duration(rng.IntN(120)) * time.Minute))
+			}
+		}
+		return alerts
+	}
+
+	for i, m := range []Matcher{
+		DefaultMatcher,
+		{Mode: MatchOpened, Threshold: 30 * time.Minute},
+		{Mode: MatchOpened, Threshold: time.Hour, On: []string{"job"}},
+		{Mode: MatchOverlap, MinOverlap: 0.5, To: to},
+		{Mode: MatchOverlap, MinOverlap: 0.1, To: to, LabelMap: map[string]string{"pod": "job"}},
+		{Mode: MatchOverlap, MinOverlap: 0.9, To: to, On: []string{"pod"}},
+	} {
+		for range 5 {
+			left, right := randomAlerts(200), randomAlerts(200)
+			require.Equal(t, pairwiseDiff(left, right, m), Diff(left, right, m), "matcher %d", i)
+		}
+	}
+}
+
+// pairwiseDiff is Diff without the index.
+func pairwiseDiff(left, right []Alert, m Matcher) []DiffRow {
+	var (
+		rows      []DiffRow
+		usedRight = make(map[int]bool)
+	)
+
+	for i := range left {
+		idx := -1
+		for j := range right {
+			if !usedRight[j] && m.Match(left[i], right[j]) {
+				idx = j
+				break
+			}
+		}
+
+		if idx == -1 {
+			rows = append(rows, DiffRow{Kind: DiffOnlyLeft, Left: &left[i]})
+			continue
+		}
+
+		usedRight[idx] = true
+		rows = append(rows, DiffRow{Kind: DiffMatched, Left: &left[i], Right: &right[idx]})
+	}
+
+	for i := range right {
+		if !usedRight[i] {
+			rows = append(rows, DiffRow{Kind: DiffOnlyRight, Right: &right[i]})
+		}
+	}
+
+	slices.SortStableFunc(rows, func(a, b DiffRow) int {
+		return a.Alert().OpenedAt.Compare(b.Alert().OpenedAt)
+	})
+
+	return rows
+}
+
+func TestLabelsKey(t *testing.T) {
+	assert.NotEqual(t, labelsKey(nil), labelsKey(map[string]string{}))
+	assert.Equal(t, labelsKey(map[string]string{"a": "1", "b": "2"}), labelsKey(map[string]string{"b": "2", "a": "1"}))
+	assert.NotEqual(t, labelsKey(map[string]string{"a": "1b"}), labelsKey(map[string]string{"a1": "b"}))
+}
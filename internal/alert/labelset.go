@@ -0,0 +1,76 @@
+package alert
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// LabelSetChange compares how often and how long alerts with the same labels
+// fired on both sides of a diff.
+type LabelSetChange struct {
+	Labels      map[string]string
+	LeftCount   int
+	RightCount  int
+	LeftFiring  time.Duration
+	RightFiring time.Duration
+}
+
+// FiringChange is the right firing time minus the left one.
+func (c LabelSetChange) FiringChange() time.Duration {
+	return c.RightFiring - c.LeftFiring
+}
+
+// Changed reports whether the label set fired differently on both sides.
+func (c LabelSetChange) Changed() bool {
+	return c.LeftCount != c.RightCount || c.LeftFiring != c.RightFiring
+}
+
+// CompareLabelSets groups the alerts of both sides of a diff by the labels m
+// compares. Unresolved left alerts fire until leftTo, and right ones until
+// rightTo. Label sets are sorted by how much their firing time changed, most
+// first.
+func CompareLabelSets(left, right []Alert, m Matcher, leftTo, rightTo time.Time) []LabelSetChange {
+	byKey := make(map[string]*LabelSetChange)
+
+	changeOf := func(ar Alert) *LabelSetChange {
+		labels := m.MatchLabels(ar.Labels)
+		key := labelsKey(labels)
+
+		c, ok := byKey[key]
+		if !ok {
+			c = &LabelSetChange{Labels: labels}
+			byKey[key] = c
+		}
+
+		return c
+	}
+
+	for _, ar := range left {
+		c := changeOf(ar)
+		c.LeftCount++
+		c.LeftFiring += ar.FiringUntil(leftTo).Sub(ar.OpenedAt)
+	}
+
+	for _, ar := range right {
+		c := changeOf(ar)
+		c.RightCount++
+		c.RightFiring += ar.FiringUntil(rightTo).Sub(ar.OpenedAt)
+	}
+
+	keys := slices.SortedFunc(maps.Keys(byKey), func(a, b string) int {
+		return cmp.Or(
+			cmp.Compare(byKey[b].FiringChange().Abs(), byKey[a].FiringChange().Abs()),
+			strings.Compare(a, b),
+		)
+	})
+
+	changes := make([]LabelSetChange, 0, len(keys))
+	for _, key := range keys {
+		changes = append(changes, *byKey[key])
+	}
+
+	return changes
+}
@@ -0,0 +1,42 @@
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareLabelSets(t *testing.T) {
+	var (
+		lastWeek = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		thisWeek = lastWeek.Add(7 * 24 * time.Hour)
+		api      = map[string]string{"job": "api", "instance": "a"}
+		db       = map[string]string{"job": "db", "instance": "b"}
+		web      = map[string]string{"job": "web", "instance": "c"}
+	)
+
+	left := []Alert{
+		{OpenedAt: lastWeek, ResolvedAt: new(lastWeek.Add(time.Hour)), Labels: api},
+		{OpenedAt: lastWeek.Add(2 * time.Hour), ResolvedAt: new(lastWeek.Add(3 * time.Hour)), Labels: api},
+		{OpenedAt: lastWeek, ResolvedAt: new(lastWeek.Add(time.Hour)), Labels: web},
+	}
+	right := []Alert{
+		{OpenedAt: thisWeek, ResolvedAt: new(thisWeek.Add(time.Hour)), Labels: web},
+		{OpenedAt: thisWeek, Labels: db},
+	}
+
+	changes := CompareLabelSets(left, right, DefaultMatcher, lastWeek.Add(24*time.Hour), thisWeek.Add(24*time.Hour))
+
+	assert.Equal(t, []LabelSetChange{
+		{Labels: db, RightCount: 1, RightFiring: 24 * time.Hour},
+		{Labels: api, LeftCount: 2, LeftFiring: 2 * time.Hour},
+		{Labels: web, LeftCount: 1, RightCount: 1, LeftFiring: time.Hour, RightFiring: time.Hour},
+	}, changes)
+	assert.Equal(t, -2*time.Hour, changes[1].FiringChange())
+	assert.True(t, changes[1].Changed())
+	assert.False(t, changes[2].Changed())
+
+	projected := CompareLabelSets(left, right, Matcher{On: []string{"job"}}, lastWeek, thisWeek)
+	assert.Equal(t, map[string]string{"job": "api"}, projected[0].Labels)
+}
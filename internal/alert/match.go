@@ -0,0 +1,121 @@
+package alert
+
+import (
+	"maps"
+	"slices"
+	"time"
+)
+
+// MatchMode selects how a Matcher compares the times of two alerts with the
+// same labels.
+type MatchMode string
+
+const (
+	// MatchOpened matches alerts that opened within the threshold of each
+	// other.
+	MatchOpened MatchMode = "opened"
+	// MatchOverlap matches alerts whose firing intervals overlap by at least
+	// the minimum overlap.
+	MatchOverlap MatchMode = "overlap"
+)
+
+// DefaultMatchThreshold is the maximum time between the openings of two
+// matching alerts in MatchOpened mode.
+const DefaultMatchThreshold = 2 * time.Minute
+
+// DefaultMatcher matches alerts that opened within DefaultMatchThreshold of
+// each other.
+var DefaultMatcher = Matcher{Mode: MatchOpened, Threshold: DefaultMatchThreshold}
+
+// Matcher decides whether two alerts are the same alert, e.g. replayed with
+// two versions of a rule. Alerts only match if their labels are identical
+// after LabelMap and On are applied.
+type Matcher struct {
+	Mode MatchMode
+	// LabelMap renames labels, from old to new name, before comparing them.
+	LabelMap map[string]string
+	// On restricts the compared labels to these, after renaming. All labels
+	// are compared if it is empty.
+	On []string
+	// Threshold is the maximum time between the openings of two alerts in
+	// MatchOpened mode.
+	Threshold time.Duration
+	// MinOverlap is the minimum share, between 0 and 1, of the combined firing
+	// time of two alerts during which both fired in MatchOverlap mode.
+	MinOverlap float64
+	// To is when unresolved alerts stop firing in MatchOverlap mode.
+	To time.Time
+	// Offset moves the first alert passed to Match by this much before
+	// comparing times, to match alerts replayed over different periods.
+	Offset time.Duration
+}
+
+// Match reports whether a and b are the same alert.
+func (m Matcher) Match(a, b Alert) bool {
+	if labelsKey(m.MatchLabels(a.Labels)) != labelsKey(m.MatchLabels(b.Labels)) {
+		return false
+	}
+
+	return m.matchTimes(a.Shift(m.Offset), b)
+}
+
+// matchTimes is Match without comparing labels or applying Offset.
+func (m Matcher) matchTimes(a, b Alert) bool {
+	if m.Mode == MatchOverlap {
+		return m.Overlap(a, b) >= m.MinOverlap
+	}
+
+	return a.OpenedAt.Sub(b.OpenedAt).Abs() <= m.Threshold
+}
+
+// MatchLabels returns the labels Match compares, leaving labels untouched.
+func (m Matcher) MatchLabels(labels map[string]string) map[string]string {
+	if len(m.LabelMap) == 0 && len(m.On) == 0 {
+		return labels
+	}
+
+	// Renamed labels replace labels that already have their new name.
+	renamed := make(map[string]string, len(labels))
+	for name, value := range labels {
+		if _, ok := m.LabelMap[name]; !ok {
+			renamed[name] = value
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(m.LabelMap)) {
+		if value, ok := labels[name]; ok {
+			renamed[m.LabelMap[name]] = value
+		}
+	}
+
+	if len(m.On) == 0 {
+		return renamed
+	}
+
+	projected := make(map[string]string, len(m.On))
+	for _, name := range m.On {
+		if value, ok := renamed[name]; ok {
+			projected[name] = value
+		}
+	}
+
+	return projected
+}
+
+// Overlap is the share of the combined firing time of a and b during which
+// both fired. Alerts that opened and resolved at the same instant overlap
+// fully.
+func (m Matcher) Overlap(a, b Alert) float64 {
+	aEnd, bEnd := a.FiringUntil(m.To), b.FiringUntil(m.To)
+
+	union := latest(aEnd, bEnd).Sub(earliest(a.OpenedAt, b.OpenedAt))
+	if union <= 0 {
+		return 1
+	}
+
+	both := earliest(aEnd, bEnd).Sub(latest(a.OpenedAt, b.OpenedAt))
+	if both <= 0 {
+		return 0
+	}
+
+	return float64(both) / float64(union)
+}
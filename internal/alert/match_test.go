@@ -0,0 +1,130 @@
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatcher_Match(t *testing.T) {
+	var (
+		base = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		to   = base.Add(4 * time.Hour)
+		api  = map[string]string{"job": "api"}
+	)
+
+	opened := Matcher{Mode: MatchOpened, Threshold: 5 * time.Minute}
+	overlap := Matcher{Mode: MatchOverlap, MinOverlap: 0.5, To: to}
+
+	for _, tt := range []struct {
+		name string
+		m    Matcher
+		a, b Alert
+		want bool
+	}{
+		{
+			name: "opened within threshold",
+			m:    opened,
+			a:    Alert{OpenedAt: base, Labels: api},
+			b:    Alert{OpenedAt: base.Add(3 * time.Minute), Labels: api},
+			want: true,
+		},
+		{
+			name: "opened beyond threshold",
+			m:    opened,
+			a:    Alert{OpenedAt: base, Labels: api},
+			b:    Alert{OpenedAt: base.Add(6 * time.Minute), Labels: api},
+		},
+		{
+			name: "different labels",
+			m:    overlap,
+			a:    Alert{OpenedAt: base, Labels: api},
+			b:    Alert{OpenedAt: base, Labels: map[string]string{"job": "db"}},
+		},
+		{
+			name: "renamed label",
+			m:    Matcher{Mode: MatchOpened, LabelMap: map[string]string{"pod": "kubernetes_pod"}},
+			a:    Alert{OpenedAt: base, Labels: map[string]string{"pod": "api-0"}},
+			b:    Alert{OpenedAt: base, Labels: map[string]string{"kubernetes_pod": "api-0"}},
+			want: true,
+		},
+		{
+			name: "projected labels",
+			m:    Matcher{Mode: MatchOpened, On: []string{"job"}},
+			a:    Alert{OpenedAt: base, Labels: map[string]string{"job": "api", "pod": "api-0"}},
+			b:    Alert{OpenedAt: base, Labels: map[string]string{"job": "api", "pod": "api-1"}},
+			want: true,
+		},
+		{
+			name: "overlap above minimum",
+			m:    overlap,
+			a:    Alert{OpenedAt: base, ResolvedAt: new(base.Add(time.Hour)), Labels: api},
+			b:    Alert{OpenedAt: base.Add(15 * time.Minute), ResolvedAt: new(base.Add(time.Hour)), Labels: api},
+			want: true,
+		},
+		{
+			name: "overlap below minimum",
+			m:    overlap,
+			a:    Alert{OpenedAt: base, ResolvedAt: new(base.Add(time.Hour)), Labels: api},
+			b:    Alert{OpenedAt: base.Add(45 * time.Minute), ResolvedAt: new(base.Add(2 * time.Hour)), Labels: api},
+		},
+		{
+			name: "unresolved alerts fire until to",
+			m:    overlap,
+			a:    Alert{OpenedAt: base, Labels: api},
+			b:    Alert{OpenedAt: base.Add(time.Hour), Labels: api},
+			want: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.m.Match(tt.a, tt.b))
+			assert.Equal(t, tt.want, tt.m.Match(tt.b, tt.a))
+		})
+	}
+}
+
+func TestMatcher_MatchLabels(t *testing.T) {
+	labels := map[string]string{"pod": "api-0", "namespace": "prod", "instance": "10.0.0.1:8080"}
+
+	assert.Equal(t, labels, DefaultMatcher.MatchLabels(labels))
+	assert.Equal(t,
+		map[string]string{"kubernetes_pod": "api-0", "namespace": "prod", "instance": "10.0.0.1:8080"},
+		Matcher{LabelMap: map[string]string{"pod": "kubernetes_pod"}}.MatchLabels(labels),
+	)
+	assert.Equal(t,
+		map[string]string{"kubernetes_pod": "api-0", "namespace": "prod"},
+		Matcher{LabelMap: map[string]string{"pod": "kubernetes_pod"}, On: []string{"kubernetes_pod", "namespace", "cluster"}}.MatchLabels(labels),
+	)
+	assert.Contains(t, labels, "pod", "labels are left untouched")
+
+	// Chained and overlapping renames don't depend on map iteration order.
+	chained := Matcher{LabelMap: map[string]string{"a": "b", "b": "c"}}
+	overlapping := Matcher{LabelMap: map[string]string{"instance": "target", "pod": "target"}}
+	for range 20 {
+		assert.Equal(t, map[string]string{"b": "1", "c": "2"}, chained.MatchLabels(map[string]string{"a": "1", "b": "2"}))
+		assert.Equal(t, map[string]string{"target": "api-0"}, overlapping.MatchLabels(map[string]string{"instance": "10.0.0.1", "pod": "api-0"}))
+	}
+}
+
+func TestMatcher_Overlap(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	m := Matcher{Mode: MatchOverlap, To: base.Add(2 * time.Hour)}
+
+	assert.InDelta(t, 0.2, m.Overlap(
+		Alert{OpenedAt: base, ResolvedAt: new(base.Add(time.Hour))},
+		Alert{OpenedAt: base.Add(45 * time.Minute), ResolvedAt: new(base.Add(75 * time.Minute))},
+	), 0.001)
+	assert.InDelta(t, 0.5, m.Overlap(
+		Alert{OpenedAt: base, ResolvedAt: new(base.Add(time.Hour))},
+		Alert{OpenedAt: base},
+	), 0.001)
+	assert.Zero(t, m.Overlap(
+		Alert{OpenedAt: base, ResolvedAt: new(base.Add(time.Hour))},
+		Alert{OpenedAt: base.Add(90 * time.Minute)},
+	))
+	assert.Equal(t, 1.0, m.Overlap(
+		Alert{OpenedAt: base, ResolvedAt: new(base)},
+		Alert{OpenedAt: base, ResolvedAt: new(base)},
+	))
+}
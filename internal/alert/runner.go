@@ -12,6 +12,16 @@ import (
 	"github.com/steved/alertreplay/internal/prometheus"
 )
 
+// Result holds the alerts replayed for a rule along with the pending
+// episodes that never fired.
+type Result struct {
+	Alerts     []Alert
+	NearMisses []NearMiss
+	// Series are the values of the rule expression. They are only set when
+	// replaying a single rule.
+	Series []Series
+}
+
 func Evaluate(
 	ctx context.Context,
 	client prometheus.Client,
@@ -20,22 +30,30 @@ func Evaluate(
 	to time.Time,
 	interval time.Duration,
 	urlBuilder dashboard.URLBuilder,
-) ([]Alert, error) {
+	restarts evaluator.Restarts,
+) (*Result, error) {
 	vectors, timestamps, err := client.QueryExpr(ctx, rule.Expr, from, to, interval)
 	if err != nil {
 		return nil, fmt.Errorf("executing queries: %w", err)
 	}
 
-	forDuration := time.Duration(rule.For)
-	eval, err := evaluator.New(rule.Alert, rule.Expr, forDuration)
+	eval, err := evaluator.New(rule)
 	if err != nil {
 		return nil, fmt.Errorf("creating rule evaluator: %w", err)
 	}
 
-	events, err := eval.Evaluate(ctx, prometheus.CachedQueryFunc(vectors), timestamps)
+	eval.SimulateRestarts(restarts)
+
+	queryFn := prometheus.ExprQueryFunc(vectors, eval.Query(), client)
+
+	events, err := eval.Evaluate(ctx, queryFn, timestamps)
 	if err != nil {
 		return nil, fmt.Errorf("evaluating rule: %w", err)
 	}
 
-	return CombineEvents(events, rule.Expr, urlBuilder), nil
+	return &Result{
+		Alerts:     CombineEvents(events, rule.Expr, urlBuilder),
+		NearMisses: NearMisses(events, time.Duration(rule.For)),
+		Series:     NewSeries(vectors, timestamps),
+	}, nil
 }
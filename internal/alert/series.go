@@ -0,0 +1,53 @@
+package alert
+
+import (
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+)
+
+// Series holds the values an alert expression returned for one label set
+// during a replay.
+type Series struct {
+	Labels map[string]string
+	Points []Point
+}
+
+// Point is the value of a series at an evaluation.
+type Point struct {
+	T time.Time
+	V float64
+}
+
+// NewSeries groups the vectors returned by a range query at timestamps into
+// series, sorted by their labels.
+func NewSeries(vectors map[int64]promql.Vector, timestamps []time.Time) []Series {
+	type entry struct {
+		lset   labels.Labels
+		points []Point
+	}
+
+	byKey := make(map[string]*entry)
+	for _, ts := range timestamps {
+		for _, sample := range vectors[ts.UnixMilli()] {
+			key := sample.Metric.String()
+			if _, ok := byKey[key]; !ok {
+				byKey[key] = &entry{lset: sample.Metric}
+			}
+
+			byKey[key].points = append(byKey[key].points, Point{T: ts, V: sample.F})
+		}
+	}
+
+	entries := slices.SortedFunc(maps.Values(byKey), func(a, b *entry) int { return labels.Compare(a.lset, b.lset) })
+
+	series := make([]Series, 0, len(entries))
+	for _, e := range entries {
+		series = append(series, Series{Labels: e.lset.Map(), Points: e.points})
+	}
+
+	return series
+}
@@ -0,0 +1,32 @@
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSeries(t *testing.T) {
+	var (
+		base = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		at   = func(i int) time.Time { return base.Add(time.Duration(i) * time.Minute) }
+		api  = labels.FromStrings("job", "api")
+		db   = labels.FromStrings("job", "db")
+	)
+
+	vectors := map[int64]promql.Vector{
+		at(0).UnixMilli(): {{F: 1, Metric: db}},
+		at(1).UnixMilli(): {{F: 2, Metric: db}, {F: 5, Metric: api}},
+		at(3).UnixMilli(): {{F: 3, Metric: db}},
+	}
+
+	assert.Equal(t, []Series{
+		{Labels: map[string]string{"job": "api"}, Points: []Point{{T: at(1), V: 5}}},
+		{Labels: map[string]string{"job": "db"}, Points: []Point{{T: at(0), V: 1}, {T: at(1), V: 2}, {T: at(3), V: 3}}},
+	}, NewSeries(vectors, []time.Time{at(0), at(1), at(2), at(3)}))
+
+	assert.Empty(t, NewSeries(nil, []time.Time{at(0)}))
+}
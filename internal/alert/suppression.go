@@ -0,0 +1,88 @@
+package alert
+
+import (
+	"slices"
+	"time"
+)
+
+// Interval is a period of time, including Start and excluding End.
+type Interval struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Suppression records the periods of an alert's lifetime during which
+// Alertmanager would not have notified about it.
+type Suppression struct {
+	Intervals []Interval
+	// Share is the fraction of the time the alert fired that is covered by
+	// Intervals.
+	Share float64
+}
+
+// NewSuppression clips and merges intervals to the period from start to end
+// in which an alert fired. It returns nil when no interval overlaps it.
+func NewSuppression(intervals []Interval, start, end time.Time) *Suppression {
+	var clipped []Interval
+	for _, iv := range intervals {
+		iv.Start = latest(iv.Start, start)
+		iv.End = earliest(iv.End, end)
+
+		if iv.Start.Before(iv.End) {
+			clipped = append(clipped, iv)
+		}
+	}
+
+	if len(clipped) == 0 {
+		return nil
+	}
+
+	slices.SortFunc(clipped, func(a, b Interval) int { return a.Start.Compare(b.Start) })
+
+	merged := clipped[:1]
+	for _, iv := range clipped[1:] {
+		last := &merged[len(merged)-1]
+		if iv.Start.After(last.End) {
+			merged = append(merged, iv)
+			continue
+		}
+
+		last.End = latest(last.End, iv.End)
+	}
+
+	var covered time.Duration
+	for _, iv := range merged {
+		covered += iv.End.Sub(iv.Start)
+	}
+
+	return &Suppression{
+		Intervals: merged,
+		Share:     float64(covered) / float64(end.Sub(start)),
+	}
+}
+
+// Covers reports whether t falls in one of the suppressed intervals. It is
+// safe to call on a nil Suppression.
+func (s *Suppression) Covers(t time.Time) bool {
+	if s == nil {
+		return false
+	}
+
+	return slices.ContainsFunc(s.Intervals, func(iv Interval) bool {
+		return !t.Before(iv.Start) && t.Before(iv.End)
+	})
+}
+
+func latest(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func earliest(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,60 @@
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSuppression(t *testing.T) {
+	var (
+		base = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		at   = func(minutes int) time.Time { return base.Add(time.Duration(minutes) * time.Minute) }
+	)
+
+	for _, tt := range []struct {
+		name      string
+		intervals []Interval
+		want      *Suppression
+	}{
+		{
+			name: "no intervals",
+		},
+		{
+			name:      "intervals outside the alert",
+			intervals: []Interval{{Start: at(-30), End: at(0)}, {Start: at(100), End: at(120)}},
+		},
+		{
+			name:      "clipped to the alert",
+			intervals: []Interval{{Start: at(-30), End: at(25)}},
+			want: &Suppression{
+				Intervals: []Interval{{Start: at(0), End: at(25)}},
+				Share:     0.25,
+			},
+		},
+		{
+			name:      "overlapping intervals are merged",
+			intervals: []Interval{{Start: at(40), End: at(60)}, {Start: at(10), End: at(20)}, {Start: at(50), End: at(70)}},
+			want: &Suppression{
+				Intervals: []Interval{{Start: at(10), End: at(20)}, {Start: at(40), End: at(70)}},
+				Share:     0.4,
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NewSuppression(tt.intervals, at(0), at(100)))
+		})
+	}
+}
+
+func TestSuppressionCovers(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := &Suppression{Intervals: []Interval{{Start: base, End: base.Add(time.Minute)}}}
+
+	assert.True(t, s.Covers(base))
+	assert.True(t, s.Covers(base.Add(30*time.Second)))
+	assert.False(t, s.Covers(base.Add(time.Minute)))
+	assert.False(t, s.Covers(base.Add(-time.Second)))
+	assert.False(t, (*Suppression)(nil).Covers(base))
+}
@@ -0,0 +1,62 @@
+package alertmanager
+
+import (
+	"time"
+
+	"github.com/prometheus/common/model"
+
+	"github.com/steved/alertreplay/internal/alert"
+)
+
+// Inhibit sets Inhibited on the alerts that an inhibit rule mutes while
+// another of the alerts fires. Unresolved alerts are considered firing until
+// to.
+func (s *Simulator) Inhibit(alerts []alert.Alert, to time.Time) {
+	lsets := make([]model.LabelSet, len(alerts))
+	for i, ar := range alerts {
+		lsets[i] = labelSet(ar.Labels)
+	}
+
+	for i := range alerts {
+		target := &alerts[i]
+
+		var intervals []alert.Interval
+		for _, rule := range s.inhibitRules {
+			if !rule.TargetMatchers.Matches(lsets[i]) {
+				continue
+			}
+
+			// As in Alertmanager, an alert matching both sides of a rule can
+			// only be inhibited by sources that don't match the target side.
+			twoSided := rule.SourceMatchers.Matches(lsets[i])
+
+			for j, source := range alerts {
+				if i == j || !rule.SourceMatchers.Matches(lsets[j]) {
+					continue
+				}
+
+				if twoSided && rule.TargetMatchers.Matches(lsets[j]) {
+					continue
+				}
+
+				if !equalLabels(rule.Equal, lsets[i], lsets[j]) {
+					continue
+				}
+
+				intervals = append(intervals, alert.Interval{Start: source.OpenedAt, End: source.FiringUntil(to)})
+			}
+		}
+
+		target.Inhibited = alert.NewSuppression(intervals, target.OpenedAt, target.FiringUntil(to))
+	}
+}
+
+func equalLabels(names map[model.LabelName]struct{}, a, b model.LabelSet) bool {
+	for name := range names {
+		if a[name] != b[name] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,79 @@
+package alertmanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/steved/alertreplay/internal/alert"
+)
+
+const inhibitConfig = `
+route:
+  receiver: default
+  group_by: [alertname]
+  group_wait: 30s
+  group_interval: 5m
+receivers:
+  - name: default
+    webhook_configs:
+      - url: http://localhost/default
+inhibit_rules:
+  - source_matchers: [severity="critical"]
+    target_matchers: [severity=~"warning|critical"]
+    equal: [job]
+`
+
+func TestInhibit(t *testing.T) {
+	cfg, err := config.Load(inhibitConfig)
+	require.NoError(t, err)
+
+	var (
+		sim  = New(cfg)
+		base = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		at   = func(minutes int) time.Time { return base.Add(time.Duration(minutes) * time.Minute) }
+	)
+
+	alerts := []alert.Alert{
+		{OpenedAt: at(0), ResolvedAt: new(at(60)), Labels: map[string]string{"alertname": "Latency", "severity": "warning", "job": "api"}},
+		{OpenedAt: at(30), ResolvedAt: new(at(45)), Labels: map[string]string{"alertname": "Down", "severity": "critical", "job": "api"}},
+		{OpenedAt: at(0), Labels: map[string]string{"alertname": "Latency", "severity": "warning", "job": "db"}},
+		{OpenedAt: at(40), ResolvedAt: new(at(50)), Labels: map[string]string{"alertname": "Errors", "severity": "critical", "job": "web"}},
+	}
+
+	sim.Inhibit(alerts, at(120))
+
+	assert.Equal(t, &alert.Suppression{
+		Intervals: []alert.Interval{{Start: at(30), End: at(45)}},
+		Share:     0.25,
+	}, alerts[0].Inhibited, "warning inhibited while critical fires for the same job")
+	assert.Nil(t, alerts[1].Inhibited, "critical alerts matching both sides are not inhibited by each other")
+	assert.Nil(t, alerts[2].Inhibited, "different job")
+	assert.Nil(t, alerts[3].Inhibited)
+
+	notifications, err := sim.Simulate(alerts[:2], at(120))
+	require.NoError(t, err)
+
+	var latency []Notification
+	for _, n := range notifications {
+		if n.Group["alertname"] == "Latency" {
+			latency = append(latency, n)
+		}
+	}
+
+	assert.Equal(t, []Notification{
+		{Time: at(0).Add(30 * time.Second), Receiver: "default", Group: map[string]string{"alertname": "Latency"}, Reason: ReasonFiring, Firing: 1},
+		{Time: at(60).Add(30 * time.Second), Receiver: "default", Group: map[string]string{"alertname": "Latency"}, Reason: ReasonResolved, Resolved: 1},
+	}, latency, "no notification while inhibited, nor once the inhibition ends as nothing changed")
+
+	alerts[0].ResolvedAt = new(at(40))
+	alerts[0].Inhibited = nil
+	sim.Inhibit(alerts, at(120))
+
+	notifications, err = sim.Simulate(alerts[:1], at(120))
+	require.NoError(t, err)
+	assert.Len(t, notifications, 1, "resolved notifications for alerts resolving while inhibited are muted")
+}
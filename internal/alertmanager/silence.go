@@ -0,0 +1,135 @@
+package alertmanager
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"gopkg.in/yaml.v3"
+
+	"github.com/steved/alertreplay/internal/alert"
+)
+
+// Silence mutes the alerts matching all of its matchers from StartsAt until
+// EndsAt.
+type Silence struct {
+	Matchers labels.Matchers
+	StartsAt time.Time
+	EndsAt   time.Time
+}
+
+type silenceFile struct {
+	Matchers []matcher `yaml:"matchers"`
+	StartsAt time.Time `yaml:"startsAt"`
+	EndsAt   time.Time `yaml:"endsAt"`
+}
+
+// matcher is either a matcher string, such as `job="api"`, or a matcher
+// object as exported by `amtool silence query -o json`.
+type matcher struct {
+	*labels.Matcher
+}
+
+func (m *matcher) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		parsed, err := labels.ParseMatcher(node.Value)
+		if err != nil {
+			return fmt.Errorf("parsing matcher %q: %w", node.Value, err)
+		}
+
+		m.Matcher = parsed
+		return nil
+	}
+
+	v1m := struct {
+		Name    string `yaml:"name"`
+		Value   string `yaml:"value"`
+		IsRegex bool   `yaml:"isRegex"`
+		IsEqual *bool  `yaml:"isEqual"`
+	}{}
+	if err := node.Decode(&v1m); err != nil {
+		return err
+	}
+
+	isEqual := v1m.IsEqual == nil || *v1m.IsEqual
+
+	var t labels.MatchType
+	switch {
+	case isEqual && !v1m.IsRegex:
+		t = labels.MatchEqual
+	case !isEqual && !v1m.IsRegex:
+		t = labels.MatchNotEqual
+	case isEqual && v1m.IsRegex:
+		t = labels.MatchRegexp
+	default:
+		t = labels.MatchNotRegexp
+	}
+
+	parsed, err := labels.NewMatcher(t, v1m.Name, v1m.Value)
+	if err != nil {
+		return fmt.Errorf("creating matcher for %q: %w", v1m.Name, err)
+	}
+
+	m.Matcher = parsed
+	return nil
+}
+
+// LoadSilences reads silences from a file, either an export of
+// `amtool silence query -o json` or a YAML list of silences with string
+// matchers:
+//
+//   - matchers: ['job="api"', 'severity=~"warning|critical"']
+//     startsAt: 2026-01-01T00:00:00Z
+//     endsAt: 2026-01-01T04:00:00Z
+func LoadSilences(path string) ([]Silence, error) {
+	file, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading file %q: %w", path, err)
+	}
+
+	var parsed []silenceFile
+	if err := yaml.Unmarshal(file, &parsed); err != nil {
+		return nil, fmt.Errorf("parsing silences: %w", err)
+	}
+
+	silences := make([]Silence, 0, len(parsed))
+	for i, s := range parsed {
+		if len(s.Matchers) == 0 {
+			return nil, fmt.Errorf("silence %d: no matchers", i)
+		}
+
+		if !s.StartsAt.Before(s.EndsAt) {
+			return nil, fmt.Errorf("silence %d: startsAt must be before endsAt", i)
+		}
+
+		silence := Silence{StartsAt: s.StartsAt, EndsAt: s.EndsAt}
+		for _, m := range s.Matchers {
+			silence.Matchers = append(silence.Matchers, m.Matcher)
+		}
+
+		silences = append(silences, silence)
+	}
+
+	return silences, nil
+}
+
+// ApplySilences sets Silenced on the alerts matching a silence while it was
+// active. Unresolved alerts are considered firing until to.
+func ApplySilences(alerts []alert.Alert, silences []Silence, to time.Time) {
+	for i := range alerts {
+		var (
+			ar        = &alerts[i]
+			lset      = labelSet(ar.Labels)
+			intervals []alert.Interval
+		)
+
+		for _, s := range silences {
+			if s.Matchers.Matches(lset) {
+				intervals = append(intervals, alert.Interval{Start: s.StartsAt, End: s.EndsAt})
+			}
+		}
+
+		ar.Silenced = alert.NewSuppression(intervals, ar.OpenedAt, ar.FiringUntil(to))
+	}
+}
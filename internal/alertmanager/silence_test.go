@@ -0,0 +1,132 @@
+package alertmanager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/steved/alertreplay/internal/alert"
+)
+
+func TestLoadSilences(t *testing.T) {
+	var (
+		start = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		end   = time.Date(2026, 1, 1, 4, 0, 0, 0, time.UTC)
+	)
+
+	for _, tt := range []struct {
+		name         string
+		content      string
+		wantMatchers []string
+		wantErr      string
+	}{
+		{
+			name: "amtool export",
+			content: `[{"id":"a1b2","matchers":[{"name":"job","value":"api","isRegex":false,"isEqual":true},` +
+				`{"name":"severity","value":"warning|critical","isRegex":true}],` +
+				`"startsAt":"2026-01-01T00:00:00Z","endsAt":"2026-01-01T04:00:00Z","createdBy":"ops","comment":"maintenance",` +
+				`"status":{"state":"expired"}}]`,
+			wantMatchers: []string{`job="api"`, `severity=~"warning|critical"`},
+		},
+		{
+			name: "yaml",
+			content: `
+- matchers: ['job!="api"', 'severity!~"info"']
+  startsAt: 2026-01-01T00:00:00Z
+  endsAt: 2026-01-01T04:00:00Z
+`,
+			wantMatchers: []string{`job!="api"`, `severity!~"info"`},
+		},
+		{
+			name:    "invalid matcher",
+			content: `[{matchers: ['job=~"("'], startsAt: 2026-01-01T00:00:00Z, endsAt: 2026-01-01T04:00:00Z}]`,
+			wantErr: "parsing matcher",
+		},
+		{
+			name:    "no matchers",
+			content: `[{startsAt: 2026-01-01T00:00:00Z, endsAt: 2026-01-01T04:00:00Z}]`,
+			wantErr: "silence 0: no matchers",
+		},
+		{
+			name:    "ends before start",
+			content: `[{matchers: ['job="api"'], startsAt: 2026-01-01T04:00:00Z, endsAt: 2026-01-01T00:00:00Z}]`,
+			wantErr: "startsAt must be before endsAt",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "silences")
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o600))
+
+			silences, err := LoadSilences(path)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, silences, 1)
+
+			var matchers []string
+			for _, m := range silences[0].Matchers {
+				matchers = append(matchers, m.String())
+			}
+
+			assert.Equal(t, tt.wantMatchers, matchers)
+			assert.True(t, silences[0].StartsAt.Equal(start))
+			assert.True(t, silences[0].EndsAt.Equal(end))
+		})
+	}
+}
+
+func TestApplySilences(t *testing.T) {
+	var (
+		base = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		at   = func(minutes int) time.Time { return base.Add(time.Duration(minutes) * time.Minute) }
+	)
+
+	silences := []Silence{
+		{StartsAt: at(-30), EndsAt: at(30), Matchers: mustParseMatchers(t, `job="api"`)},
+		{StartsAt: at(200), EndsAt: at(300), Matchers: mustParseMatchers(t, `job="api"`)},
+	}
+
+	alerts := []alert.Alert{
+		{OpenedAt: at(0), ResolvedAt: new(at(60)), Labels: map[string]string{"alertname": "Down", "job": "api"}},
+		{OpenedAt: at(0), ResolvedAt: new(at(20)), Labels: map[string]string{"alertname": "Down", "job": "api", "instance": "b"}},
+		{OpenedAt: at(0), Labels: map[string]string{"alertname": "Down", "job": "db"}},
+	}
+
+	ApplySilences(alerts, silences, at(120))
+
+	assert.Equal(t, &alert.Suppression{
+		Intervals: []alert.Interval{{Start: at(0), End: at(30)}},
+		Share:     0.5,
+	}, alerts[0].Silenced)
+	assert.Equal(t, 1.0, alerts[1].Silenced.Share)
+	assert.Nil(t, alerts[2].Silenced)
+
+	cfg, err := config.Load(testConfig)
+	require.NoError(t, err)
+
+	notifications, err := New(cfg).Simulate(alerts[:2], at(120))
+	require.NoError(t, err)
+
+	assert.Equal(t, []Notification{
+		{Time: at(30).Add(30 * time.Second), Receiver: "default", Group: map[string]string{"alertname": "Down"}, Reason: ReasonFiring, Firing: 1},
+		{Time: at(60).Add(30 * time.Second), Receiver: "default", Group: map[string]string{"alertname": "Down"}, Reason: ReasonResolved, Resolved: 1},
+	}, notifications, "silenced alerts are not notified until the silence ends")
+}
+
+func mustParseMatchers(t *testing.T, s string) []*labels.Matcher {
+	t.Helper()
+
+	matchers, err := labels.ParseMatchers(s)
+	require.NoError(t, err)
+
+	return matchers
+}
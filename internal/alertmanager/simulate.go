@@ -0,0 +1,411 @@
+package alertmanager
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/alertmanager/inhibit"
+	"github.com/prometheus/alertmanager/timeinterval"
+	"github.com/prometheus/common/model"
+
+	"github.com/steved/alertreplay/internal/alert"
+)
+
+// Reason is why Alertmanager notified a receiver about an alert group.
+type Reason string
+
+const (
+	// ReasonFiring is a notification about alerts not yet notified as firing.
+	ReasonFiring Reason = "firing"
+	// ReasonResolved is a notification about alerts that resolved.
+	ReasonResolved Reason = "resolved"
+	// ReasonRepeat is a notification repeated after repeat_interval with no
+	// change to the group.
+	ReasonRepeat Reason = "repeat"
+)
+
+// Notification is a message Alertmanager sends to a receiver for an alert
+// group.
+type Notification struct {
+	Time     time.Time
+	Receiver string
+	Group    map[string]string
+	Reason   Reason
+	Firing   int
+	// Resolved is only counted for receivers with send_resolved enabled.
+	Resolved int
+}
+
+// Simulator replays alerts through an Alertmanager routing tree.
+type Simulator struct {
+	route        *dispatch.Route
+	intervener   *timeinterval.Intervener
+	receivers    map[string]receiver
+	inhibitRules []*inhibit.InhibitRule
+}
+
+type receiver struct {
+	integrations int
+	sendResolved bool
+}
+
+// LoadFile creates a Simulator from an Alertmanager configuration file.
+func LoadFile(path string) (*Simulator, error) {
+	cfg, err := config.LoadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("loading alertmanager config: %w", err)
+	}
+
+	return New(cfg), nil
+}
+
+func New(cfg *config.Config) *Simulator {
+	intervals := make(map[string][]timeinterval.TimeInterval)
+	for _, ti := range cfg.MuteTimeIntervals {
+		intervals[ti.Name] = ti.TimeIntervals
+	}
+	for _, ti := range cfg.TimeIntervals {
+		intervals[ti.Name] = ti.TimeIntervals
+	}
+
+	receivers := make(map[string]receiver, len(cfg.Receivers))
+	for _, r := range cfg.Receivers {
+		receivers[r.Name] = newReceiver(r)
+	}
+
+	inhibitRules := make([]*inhibit.InhibitRule, 0, len(cfg.InhibitRules))
+	for _, r := range cfg.InhibitRules {
+		inhibitRules = append(inhibitRules, inhibit.NewInhibitRule(r))
+	}
+
+	return &Simulator{
+		route:        dispatch.NewRoute(cfg.Route, nil),
+		intervener:   timeinterval.NewIntervener(intervals),
+		receivers:    receivers,
+		inhibitRules: inhibitRules,
+	}
+}
+
+// newReceiver counts the integrations of r and whether any of them sends
+// resolved notifications.
+func newReceiver(r config.Receiver) receiver {
+	type resolvable interface{ SendResolved() bool }
+
+	var (
+		rcv = receiver{}
+		v   = reflect.ValueOf(r)
+	)
+
+	for i := range v.NumField() {
+		field := v.Field(i)
+		if field.Kind() != reflect.Slice {
+			continue
+		}
+
+		for j := range field.Len() {
+			integration, ok := field.Index(j).Interface().(resolvable)
+			if !ok {
+				continue
+			}
+
+			rcv.integrations++
+			rcv.sendResolved = rcv.sendResolved || integration.SendResolved()
+		}
+	}
+
+	return rcv
+}
+
+// aggrGroup collects the alerts routed to the same route with the same
+// group_by labels.
+type aggrGroup struct {
+	route  *dispatch.Route
+	labels model.LabelSet
+	alerts []groupAlert
+}
+
+type groupAlert struct {
+	fingerprint model.Fingerprint
+	openedAt    time.Time
+	resolvedAt  *time.Time
+	inhibited   *alert.Suppression
+	silenced    *alert.Suppression
+}
+
+// mutedAt reports whether Alertmanager drops the alert from notifications
+// at t. Alertmanager mutes by labels regardless of state, so a resolved alert
+// stays muted if it resolved while muted.
+func (a groupAlert) mutedAt(t time.Time) bool {
+	if !a.firingAt(t) {
+		t = a.resolvedAt.Add(-time.Nanosecond)
+	}
+
+	return a.inhibited.Covers(t) || a.silenced.Covers(t)
+}
+
+func (a groupAlert) firingAt(t time.Time) bool {
+	return a.resolvedAt == nil || a.resolvedAt.After(t)
+}
+
+// logEntry is the notification log entry of a group: the alerts included in
+// the last notification.
+type logEntry struct {
+	time     time.Time
+	firing   map[model.Fingerprint]struct{}
+	resolved map[model.Fingerprint]struct{}
+}
+
+// Simulate returns the notifications Alertmanager would have sent for alerts
+// up to the given time. Alerts are received when they open and resolve at
+// their resolved time; unresolved alerts keep firing until to. Alerts are
+// left out of notifications while they are inhibited or silenced, see Inhibit
+// and ApplySilences.
+func (s *Simulator) Simulate(alerts []alert.Alert, to time.Time) ([]Notification, error) {
+	groups := make(map[string]*aggrGroup)
+
+	for _, ar := range alerts {
+		lset := labelSet(ar.Labels)
+
+		for _, route := range s.route.Match(lset) {
+			groupLabels := groupLabels(route, lset)
+			key := route.ID() + groupLabels.String()
+
+			group, ok := groups[key]
+			if !ok {
+				group = &aggrGroup{route: route, labels: groupLabels}
+				groups[key] = group
+			}
+
+			group.alerts = append(group.alerts, groupAlert{
+				fingerprint: lset.Fingerprint(),
+				openedAt:    ar.OpenedAt,
+				resolvedAt:  ar.ResolvedAt,
+				inhibited:   ar.Inhibited,
+				silenced:    ar.Silenced,
+			})
+		}
+	}
+
+	var notifications []Notification
+	for _, key := range slices.Sorted(maps.Keys(groups)) {
+		groupNotifications, err := s.simulateGroup(groups[key], to)
+		if err != nil {
+			return nil, err
+		}
+
+		notifications = append(notifications, groupNotifications...)
+	}
+
+	slices.SortStableFunc(notifications, func(a, b Notification) int {
+		if c := a.Time.Compare(b.Time); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Receiver, b.Receiver)
+	})
+
+	return notifications, nil
+}
+
+func groupLabels(route *dispatch.Route, lset model.LabelSet) model.LabelSet {
+	if route.RouteOpts.GroupByAll {
+		return lset.Clone()
+	}
+
+	labels := make(model.LabelSet)
+	for name := range route.RouteOpts.GroupBy {
+		if value, ok := lset[name]; ok {
+			labels[name] = value
+		}
+	}
+
+	return labels
+}
+
+// simulateGroup flushes the group after group_wait and every group_interval
+// after that, until all its alerts resolved. Alerts opening after that start
+// a new group with the same key, which shares the notification log.
+func (s *Simulator) simulateGroup(group *aggrGroup, to time.Time) ([]Notification, error) {
+	var (
+		opts = group.route.RouteOpts
+		rcv  = s.receivers[opts.Receiver]
+	)
+
+	if rcv.integrations == 0 {
+		return nil, nil
+	}
+
+	slices.SortStableFunc(group.alerts, func(a, b groupAlert) int {
+		return a.openedAt.Compare(b.openedAt)
+	})
+
+	var (
+		notifications []Notification
+		entry         *logEntry
+		next          int
+	)
+
+	for next < len(group.alerts) {
+		var (
+			active  = make(map[model.Fingerprint]groupAlert)
+			flushAt = group.alerts[next].openedAt.Add(opts.GroupWait)
+		)
+
+		for ; !flushAt.After(to); flushAt = flushAt.Add(opts.GroupInterval) {
+			for next < len(group.alerts) && !group.alerts[next].openedAt.After(flushAt) {
+				active[group.alerts[next].fingerprint] = group.alerts[next]
+				next++
+			}
+
+			var (
+				firing   = make(map[model.Fingerprint]struct{})
+				resolved = make(map[model.Fingerprint]struct{})
+			)
+
+			for fp, ar := range active {
+				if ar.mutedAt(flushAt) {
+					continue
+				}
+
+				if ar.firingAt(flushAt) {
+					firing[fp] = struct{}{}
+				} else {
+					resolved[fp] = struct{}{}
+				}
+			}
+
+			muted, err := s.muted(group.route, flushAt)
+			if err != nil {
+				return nil, err
+			}
+
+			// Flushes with every alert muted stop before the notification log.
+			if len(firing) == 0 && len(resolved) == 0 {
+				muted = true
+			}
+
+			if reason, ok := needsUpdate(entry, firing, resolved, rcv.sendResolved, opts.RepeatInterval, flushAt); ok && !muted {
+				entry = &logEntry{time: flushAt, firing: firing, resolved: resolved}
+
+				// Integrations drop resolved alerts without send_resolved, and
+				// don't send a notification when nothing is left.
+				n := Notification{
+					Time:     flushAt,
+					Receiver: opts.Receiver,
+					Group:    labelSetToMap(group.labels),
+					Reason:   reason,
+					Firing:   len(firing),
+				}
+				if rcv.sendResolved {
+					n.Resolved = len(resolved)
+				}
+
+				if n.Firing > 0 || n.Resolved > 0 {
+					notifications = append(notifications, n)
+				}
+			}
+
+			for fp, ar := range active {
+				if !ar.firingAt(flushAt) {
+					delete(active, fp)
+				}
+			}
+
+			if len(active) == 0 {
+				break
+			}
+		}
+
+		if flushAt.After(to) {
+			break
+		}
+	}
+
+	return notifications, nil
+}
+
+// needsUpdate mirrors the deduplication of Alertmanager's notification
+// pipeline.
+func needsUpdate(
+	entry *logEntry,
+	firing, resolved map[model.Fingerprint]struct{},
+	sendResolved bool,
+	repeat time.Duration,
+	now time.Time,
+) (Reason, bool) {
+	if entry == nil {
+		return ReasonFiring, len(firing) > 0
+	}
+
+	if !isSubset(firing, entry.firing) {
+		return ReasonFiring, true
+	}
+
+	if len(firing) == 0 {
+		return ReasonResolved, len(entry.firing) > 0
+	}
+
+	if sendResolved && !isSubset(resolved, entry.resolved) {
+		return ReasonResolved, true
+	}
+
+	return ReasonRepeat, entry.time.Before(now.Add(-repeat))
+}
+
+func isSubset(subset, set map[model.Fingerprint]struct{}) bool {
+	for fp := range subset {
+		if _, ok := set[fp]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// muted reports whether the route's time intervals mute notifications at t.
+func (s *Simulator) muted(route *dispatch.Route, t time.Time) (bool, error) {
+	if names := route.RouteOpts.MuteTimeIntervals; len(names) > 0 {
+		muted, _, err := s.intervener.Mutes(names, t)
+		if err != nil {
+			return false, err
+		}
+
+		if muted {
+			return true, nil
+		}
+	}
+
+	if names := route.RouteOpts.ActiveTimeIntervals; len(names) > 0 {
+		active, _, err := s.intervener.Mutes(names, t)
+		if err != nil {
+			return false, err
+		}
+
+		return !active, nil
+	}
+
+	return false, nil
+}
+
+func labelSet(m map[string]string) model.LabelSet {
+	lset := make(model.LabelSet, len(m))
+	for name, value := range m {
+		lset[model.LabelName(name)] = model.LabelValue(value)
+	}
+
+	return lset
+}
+
+func labelSetToMap(lset model.LabelSet) map[string]string {
+	m := make(map[string]string, len(lset))
+	for name, value := range lset {
+		m[string(name)] = string(value)
+	}
+
+	return m
+}
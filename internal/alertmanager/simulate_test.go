@@ -0,0 +1,142 @@
+package alertmanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/steved/alertreplay/internal/alert"
+)
+
+const testConfig = `
+route:
+  receiver: default
+  group_by: [alertname]
+  group_wait: 30s
+  group_interval: 5m
+  repeat_interval: 4h
+  routes:
+    - receiver: pager
+      matchers: [severity="page"]
+      continue: true
+    - receiver: quiet
+      matchers: [team="quiet"]
+    - receiver: nights-off
+      matchers: [team="nights"]
+      active_time_intervals: [business-hours]
+    - receiver: blackhole
+      matchers: [team="none"]
+receivers:
+  - name: default
+    webhook_configs:
+      - url: http://localhost/default
+  - name: pager
+    webhook_configs:
+      - url: http://localhost/pager
+  - name: quiet
+    webhook_configs:
+      - url: http://localhost/quiet
+        send_resolved: false
+  - name: nights-off
+    webhook_configs:
+      - url: http://localhost/nights
+  - name: blackhole
+time_intervals:
+  - name: business-hours
+    time_intervals:
+      - times:
+          - start_time: "09:00"
+            end_time: "17:00"
+`
+
+func TestSimulate(t *testing.T) {
+	cfg, err := config.Load(testConfig)
+	require.NoError(t, err)
+
+	sim := New(cfg)
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, tt := range []struct {
+		name   string
+		alerts []alert.Alert
+		to     time.Time
+		want   []Notification
+	}{
+		{
+			name: "alerts opening within group_wait are batched",
+			alerts: []alert.Alert{
+				{OpenedAt: base, ResolvedAt: new(base.Add(20 * time.Minute)), Labels: map[string]string{"alertname": "Down", "job": "api"}},
+				{OpenedAt: base.Add(10 * time.Second), ResolvedAt: new(base.Add(20 * time.Minute)), Labels: map[string]string{"alertname": "Down", "job": "db"}},
+				{OpenedAt: base.Add(2 * time.Minute), ResolvedAt: new(base.Add(20 * time.Minute)), Labels: map[string]string{"alertname": "Down", "job": "web"}},
+			},
+			to: base.Add(time.Hour),
+			want: []Notification{
+				{Time: base.Add(30 * time.Second), Receiver: "default", Group: map[string]string{"alertname": "Down"}, Reason: ReasonFiring, Firing: 2},
+				{Time: base.Add(5*time.Minute + 30*time.Second), Receiver: "default", Group: map[string]string{"alertname": "Down"}, Reason: ReasonFiring, Firing: 3},
+				{Time: base.Add(20*time.Minute + 30*time.Second), Receiver: "default", Group: map[string]string{"alertname": "Down"}, Reason: ReasonResolved, Resolved: 3},
+			},
+		},
+		{
+			name: "unchanged groups repeat after repeat_interval",
+			alerts: []alert.Alert{
+				{OpenedAt: base, Labels: map[string]string{"alertname": "Down", "job": "api"}},
+			},
+			to: base.Add(9 * time.Hour),
+			want: []Notification{
+				{Time: base.Add(30 * time.Second), Receiver: "default", Group: map[string]string{"alertname": "Down"}, Reason: ReasonFiring, Firing: 1},
+				{Time: base.Add(4*time.Hour + 5*time.Minute + 30*time.Second), Receiver: "default", Group: map[string]string{"alertname": "Down"}, Reason: ReasonRepeat, Firing: 1},
+				{Time: base.Add(8*time.Hour + 10*time.Minute + 30*time.Second), Receiver: "default", Group: map[string]string{"alertname": "Down"}, Reason: ReasonRepeat, Firing: 1},
+			},
+		},
+		{
+			name: "continue routes to the next matching route",
+			alerts: []alert.Alert{
+				{OpenedAt: base, ResolvedAt: new(base.Add(time.Minute)), Labels: map[string]string{"alertname": "Down", "severity": "page", "team": "quiet"}},
+			},
+			to: base.Add(time.Hour),
+			want: []Notification{
+				{Time: base.Add(30 * time.Second), Receiver: "pager", Group: map[string]string{"alertname": "Down"}, Reason: ReasonFiring, Firing: 1},
+				{Time: base.Add(30 * time.Second), Receiver: "quiet", Group: map[string]string{"alertname": "Down"}, Reason: ReasonFiring, Firing: 1},
+				{Time: base.Add(5*time.Minute + 30*time.Second), Receiver: "pager", Group: map[string]string{"alertname": "Down"}, Reason: ReasonResolved, Resolved: 1},
+			},
+		},
+		{
+			name: "alerts resolving before group_wait are never notified",
+			alerts: []alert.Alert{
+				{OpenedAt: base, ResolvedAt: new(base.Add(10 * time.Second)), Labels: map[string]string{"alertname": "Blip"}},
+			},
+			to: base.Add(time.Hour),
+		},
+		{
+			name: "notifications outside active time intervals are held back",
+			alerts: []alert.Alert{
+				{OpenedAt: base.Add(-6 * time.Hour), Labels: map[string]string{"alertname": "Down", "team": "nights"}},
+			},
+			to: base.Add(-2 * time.Hour),
+			want: []Notification{
+				{Time: base.Add(-3*time.Hour + 30*time.Second), Receiver: "nights-off", Group: map[string]string{"alertname": "Down"}, Reason: ReasonFiring, Firing: 1},
+			},
+		},
+		{
+			name: "receivers without integrations send nothing",
+			alerts: []alert.Alert{
+				{OpenedAt: base, Labels: map[string]string{"alertname": "Down", "team": "none"}},
+			},
+			to: base.Add(time.Hour),
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sim.Simulate(tt.alerts, tt.to)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	_, err := LoadFile("/nonexistent/alertmanager.yml")
+	assert.ErrorContains(t, err, "loading alertmanager config")
+}
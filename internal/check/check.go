@@ -0,0 +1,145 @@
+// Package check asserts limits on replayed and diffed alerts, so that a
+// pipeline can fail on rule changes that alert too much.
+package check
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/steved/alertreplay/internal/alert"
+)
+
+// ExitCode is the exit status of a command whose checks failed.
+const ExitCode = 2
+
+// Violation is a check that failed.
+type Violation struct {
+	Check string
+	Value string
+	Limit string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s value=%s limit=%s", v.Check, v.Value, v.Limit)
+}
+
+// Error reports the checks that failed.
+type Error struct {
+	Violations []Violation
+}
+
+func (e *Error) Error() string {
+	reasons := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		reasons = append(reasons, v.String())
+	}
+
+	return "check failed: " + strings.Join(reasons, "; ")
+}
+
+// ExitCode makes the command exit with ExitCode.
+func (e *Error) ExitCode() int {
+	return ExitCode
+}
+
+// Result returns an *Error reporting violations, or nil if there are none.
+func Result(violations []Violation) error {
+	if len(violations) == 0 {
+		return nil
+	}
+
+	return &Error{Violations: violations}
+}
+
+// ReplayLimits are the limits on the alerts of a replay. Nil limits aren't
+// checked.
+type ReplayLimits struct {
+	MaxAlerts      *int
+	MaxFiringRatio *float64
+	MaxFlaps       *int
+}
+
+// Check returns the limits that alerts replayed from from to to exceed.
+func (l ReplayLimits) Check(alerts []alert.Alert, from, to time.Time) []Violation {
+	var violations []Violation
+
+	if l.MaxAlerts != nil && len(alerts) > *l.MaxAlerts {
+		violations = append(violations, Violation{
+			Check: "max-alerts",
+			Value: strconv.Itoa(len(alerts)),
+			Limit: strconv.Itoa(*l.MaxAlerts),
+		})
+	}
+
+	if l.MaxFiringRatio != nil {
+		if ratio := alert.FiringRatio(alerts, from, to); ratio > *l.MaxFiringRatio {
+			violations = append(violations, Violation{
+				Check: "max-firing-ratio",
+				Value: formatRatio(ratio),
+				Limit: formatRatio(*l.MaxFiringRatio),
+			})
+		}
+	}
+
+	if l.MaxFlaps != nil {
+		if flaps := alert.Flaps(alerts); flaps > *l.MaxFlaps {
+			violations = append(violations, Violation{
+				Check: "max-flaps",
+				Value: strconv.Itoa(flaps),
+				Limit: strconv.Itoa(*l.MaxFlaps),
+			})
+		}
+	}
+
+	return violations
+}
+
+// FailOn selects the differences that fail a diff.
+type FailOn string
+
+const (
+	FailOnNone FailOn = "none"
+	// FailOnNewAlerts fails on alerts that only fire on the right.
+	FailOnNewAlerts FailOn = "new-alerts"
+	// FailOnAnyChange fails on alerts that only fire on one side, or fire for
+	// a different time on both.
+	FailOnAnyChange FailOn = "any-change"
+)
+
+// Diff returns a violation if rows contain the differences failOn selects.
+func Diff(failOn FailOn, rows []alert.DiffRow) []Violation {
+	var count int
+	for _, row := range rows {
+		switch {
+		case row.Kind == alert.DiffOnlyRight:
+			count++
+		case failOn == FailOnAnyChange && changed(row):
+			count++
+		}
+	}
+
+	if failOn == FailOnNone || count == 0 {
+		return nil
+	}
+
+	return []Violation{{Check: string(failOn), Value: strconv.Itoa(count), Limit: "0"}}
+}
+
+// changed reports whether the alert of a row fired only on the left, or for
+// a different time on both sides.
+func changed(row alert.DiffRow) bool {
+	if row.Kind == alert.DiffOnlyLeft {
+		return true
+	}
+
+	resolved, ok := row.ResolvedDelta()
+
+	return row.Kind == alert.DiffMatched && (!ok || resolved != row.OpenedDelta())
+}
+
+func formatRatio(ratio float64) string {
+	return strconv.FormatFloat(math.Round(ratio*1e4)/1e4, 'f', -1, 64)
+}
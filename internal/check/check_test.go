@@ -0,0 +1,128 @@
+package check
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/steved/alertreplay/internal/alert"
+)
+
+func TestReplayLimits_Check(t *testing.T) {
+	var (
+		from = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		to   = from.Add(10 * time.Hour)
+		api  = map[string]string{"job": "api"}
+		db   = map[string]string{"job": "db"}
+	)
+
+	alerts := []alert.Alert{
+		{OpenedAt: from, ResolvedAt: new(from.Add(time.Hour)), Labels: api},
+		{OpenedAt: from.Add(2 * time.Hour), ResolvedAt: new(from.Add(3 * time.Hour)), Labels: api},
+		{OpenedAt: from.Add(9 * time.Hour), Labels: db},
+	}
+
+	for _, tt := range []struct {
+		name   string
+		limits ReplayLimits
+		want   []Violation
+	}{
+		{
+			name: "no limits",
+		},
+		{
+			name:   "within limits",
+			limits: ReplayLimits{MaxAlerts: new(3), MaxFiringRatio: new(0.3), MaxFlaps: new(1)},
+		},
+		{
+			name:   "max alerts",
+			limits: ReplayLimits{MaxAlerts: new(2)},
+			want:   []Violation{{Check: "max-alerts", Value: "3", Limit: "2"}},
+		},
+		{
+			name:   "max alerts of zero",
+			limits: ReplayLimits{MaxAlerts: new(0)},
+			want:   []Violation{{Check: "max-alerts", Value: "3", Limit: "0"}},
+		},
+		{
+			name:   "all limits",
+			limits: ReplayLimits{MaxAlerts: new(1), MaxFiringRatio: new(0.05), MaxFlaps: new(0)},
+			want: []Violation{
+				{Check: "max-alerts", Value: "3", Limit: "1"},
+				{Check: "max-firing-ratio", Value: "0.3", Limit: "0.05"},
+				{Check: "max-flaps", Value: "1", Limit: "0"},
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.limits.Check(alerts, from, to))
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	var (
+		base  = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		same  = &alert.Alert{OpenedAt: base, ResolvedAt: new(base.Add(time.Hour))}
+		later = &alert.Alert{OpenedAt: base.Add(time.Minute), ResolvedAt: new(base.Add(time.Hour))}
+	)
+
+	unchanged := alert.DiffRow{Kind: alert.DiffMatched, Left: same, Right: same}
+	shorter := alert.DiffRow{Kind: alert.DiffMatched, Left: same, Right: later}
+	onlyLeft := alert.DiffRow{Kind: alert.DiffOnlyLeft, Left: same}
+	onlyRight := alert.DiffRow{Kind: alert.DiffOnlyRight, Right: same}
+
+	for _, tt := range []struct {
+		name   string
+		failOn FailOn
+		rows   []alert.DiffRow
+		want   []Violation
+	}{
+		{
+			name:   "none",
+			failOn: FailOnNone,
+			rows:   []alert.DiffRow{onlyRight},
+		},
+		{
+			name:   "new alerts",
+			failOn: FailOnNewAlerts,
+			rows:   []alert.DiffRow{unchanged, shorter, onlyLeft, onlyRight},
+			want:   []Violation{{Check: "new-alerts", Value: "1", Limit: "0"}},
+		},
+		{
+			name:   "no new alerts",
+			failOn: FailOnNewAlerts,
+			rows:   []alert.DiffRow{unchanged, shorter, onlyLeft},
+		},
+		{
+			name:   "any change",
+			failOn: FailOnAnyChange,
+			rows:   []alert.DiffRow{unchanged, shorter, onlyLeft, onlyRight},
+			want:   []Violation{{Check: "any-change", Value: "3", Limit: "0"}},
+		},
+		{
+			name:   "no change",
+			failOn: FailOnAnyChange,
+			rows:   []alert.DiffRow{unchanged},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Diff(tt.failOn, tt.rows))
+		})
+	}
+}
+
+func TestResult(t *testing.T) {
+	assert.NoError(t, Result(nil))
+
+	err := Result([]Violation{
+		{Check: "max-alerts", Value: "12", Limit: "10"},
+		{Check: "max-flaps", Value: "3", Limit: "1"},
+	})
+	assert.EqualError(t, err, "check failed: max-alerts value=12 limit=10; max-flaps value=3 limit=1")
+
+	var exitCoder interface{ ExitCode() int }
+	assert.ErrorAs(t, err, &exitCoder)
+	assert.Equal(t, ExitCode, exitCoder.ExitCode())
+}
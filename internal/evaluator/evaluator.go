@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"github.com/prometheus/prometheus/promql"
 	"github.com/prometheus/prometheus/promql/parser"
 	"github.com/prometheus/prometheus/rules"
 	zlog "github.com/rs/zerolog/log"
@@ -17,43 +19,108 @@ type EventType int
 const (
 	EventOpened EventType = iota
 	EventResolved
+	// EventNearMiss marks a pending alert whose condition cleared before its
+	// `for` duration elapsed, so it never fired.
+	EventNearMiss
 )
 
 type Event struct {
 	Time   time.Time
 	Labels map[string]string
 	Type   EventType
+	// ActiveAt is when the alert condition first became true, i.e. when the
+	// alert went pending. It is set on opened and near miss events.
+	ActiveAt time.Time
+	// Annotations are the rule annotations rendered when the alert opened.
+	Annotations map[string]string
+	// Values is set on opened events and keeps being updated for as long as
+	// the alert fires.
+	Values *Values
+	// KeepFiringSince is set on resolved events when the alert was held
+	// firing by keep_firing_for after its expression stopped returning results.
+	KeepFiringSince time.Time
+}
+
+// Values summarises the expression value of an alert while it was firing,
+// as returned by the query function at each evaluation.
+type Values struct {
+	// Firing is the value at the evaluation the alert fired.
+	Firing float64
+	Peak   float64
+	Min    float64
+	// Last is the value at the final evaluation where the expression still
+	// returned the alert, before it resolved or was held by keep_firing_for.
+	Last float64
+}
+
+func (v *Values) observe(value float64) {
+	v.Peak = max(v.Peak, value)
+	v.Min = min(v.Min, value)
+	v.Last = value
 }
 
 type Evaluator struct {
-	rule *rules.AlertingRule
+	rule    *rules.AlertingRule
+	newRule func(restored bool) *rules.AlertingRule
+	tracker *tracker
+	restart *restartState
 }
 
-func New(name string, expr string, forDuration time.Duration) (*Evaluator, error) {
-	parsedExpr, err := parser.ParseExpr(expr)
+func New(r rulefmt.Rule) (*Evaluator, error) {
+	parsedExpr, err := parser.ParseExpr(r.Expr)
 	if err != nil {
 		return nil, fmt.Errorf("parsing expression: %w", err)
 	}
 
-	rule := rules.NewAlertingRule(
-		name,
-		parsedExpr,
-		forDuration,
-		0,
-		labels.EmptyLabels(),
-		labels.EmptyLabels(),
-		labels.EmptyLabels(),
-		"",
-		true, // treat as if state was restored to avoid initial pending
-		slog.Default(),
-	)
+	newRule := func(restored bool) *rules.AlertingRule {
+		return rules.NewAlertingRule(
+			r.Alert,
+			parsedExpr,
+			time.Duration(r.For),
+			time.Duration(r.KeepFiringFor),
+			labels.FromMap(r.Labels),
+			labels.FromMap(r.Annotations),
+			labels.EmptyLabels(),
+			"",
+			restored,
+			slog.Default(),
+		)
+	}
+
+	return &Evaluator{
+		// Treat as if state was restored to avoid initial pending.
+		rule:    newRule(true),
+		newRule: newRule,
+		tracker: &tracker{
+			firing:  make(map[string]*firingAlert),
+			pending: make(map[string]*pendingAlert),
+		},
+	}, nil
+}
 
-	return &Evaluator{rule: rule}, nil
+// Query returns the rule expression as it is passed to the query function.
+// It can differ from the original expression in formatting.
+func (e *Evaluator) Query() string {
+	return e.rule.Query().String()
 }
 
 type firingAlert struct {
-	labels  map[string]string
-	firedAt time.Time
+	labels          map[string]string
+	firedAt         time.Time
+	keepFiringSince time.Time
+	values          *Values
+}
+
+type pendingAlert struct {
+	labels   map[string]string
+	activeAt time.Time
+}
+
+// tracker turns the active alerts of consecutive evaluations into events.
+type tracker struct {
+	events  []Event
+	firing  map[string]*firingAlert
+	pending map[string]*pendingAlert
 }
 
 func (e *Evaluator) Evaluate(
@@ -61,68 +128,161 @@ func (e *Evaluator) Evaluate(
 	queryFn rules.QueryFunc,
 	timestamps []time.Time,
 ) ([]Event, error) {
-	var events []Event
-	firing := make(map[string]*firingAlert)
-
 	for _, ts := range timestamps {
-		_, err := e.rule.Eval(ctx, 0, ts, queryFn, nil, 0)
-		if err != nil {
-			return nil, fmt.Errorf("evaluating at %s: %w", ts.Format(time.RFC3339), err)
+		if _, err := e.Step(ctx, queryFn, ts); err != nil {
+			return nil, err
 		}
+	}
+
+	return e.Events(), nil
+}
+
+// Step evaluates the rule at a single timestamp and returns the ALERTS and
+// ALERTS_FOR_STATE samples Prometheus would write for it. Timestamps must be
+// passed in increasing order across calls.
+func (e *Evaluator) Step(ctx context.Context, queryFn rules.QueryFunc, ts time.Time) (promql.Vector, error) {
+	e.restartIfDue(ts)
+
+	// A restarted rule reports its alerts as pending until their 'for' state
+	// is restored. Alerts that fired before the restart keep firing through
+	// it, as the restored rule continues them.
+	restoring := !e.rule.Restored()
+
+	vector, err := e.rule.Eval(ctx, 0, ts, queryFn, nil, 0)
+	if err != nil {
+		return nil, fmt.Errorf("evaluating at %s: %w", ts.Format(time.RFC3339), err)
+	}
 
-		currentlyFiring := make(map[string]struct{})
+	e.restoreIfDue(ctx, ts, vector)
+
+	var (
+		currentlyFiring  = make(map[string]struct{})
+		currentlyPending = make(map[string]struct{})
+	)
 
-		e.rule.ForEachActiveAlert(func(alert *rules.Alert) {
-			if alert.State != rules.StateFiring {
-				return
-			}
+	e.rule.ForEachActiveAlert(func(alert *rules.Alert) {
+		key := alert.Labels.String()
 
-			key := alert.Labels.String()
+		if _, ok := e.tracker.firing[key]; ok && restoring {
 			currentlyFiring[key] = struct{}{}
+			return
+		}
 
-			if _, ok := firing[key]; ok {
-				return
-			}
-
-			lbls := make(map[string]string)
-			alert.Labels.Range(func(l labels.Label) {
-				lbls[l.Name] = l.Value
-			})
-
-			firing[key] = &firingAlert{
-				labels:  lbls,
-				firedAt: alert.FiredAt,
-			}
-
-			events = append(events, Event{
-				Time:   alert.FiredAt,
-				Labels: lbls,
-				Type:   EventOpened,
-			})
-
-			zlog.Debug().
-				Time("firedAt", alert.FiredAt).
-				Interface("labels", lbls).
-				Msg("alert opened")
-		})
+		switch alert.State {
+		case rules.StatePending:
+			currentlyPending[key] = struct{}{}
+			e.tracker.observePending(key, alert)
+		case rules.StateFiring:
+			currentlyFiring[key] = struct{}{}
+			e.tracker.observeFiring(key, alert)
+		}
+	})
+
+	e.tracker.resolve(ts, currentlyFiring, currentlyPending)
+
+	return vector, nil
+}
 
-		for key, alert := range firing {
-			if _, stillFiring := currentlyFiring[key]; !stillFiring {
-				events = append(events, Event{
-					Time:   ts,
-					Labels: alert.labels,
-					Type:   EventResolved,
-				})
-
-				zlog.Debug().
-					Time("resolvedAt", ts).
-					Interface("labels", alert.labels).
-					Msg("alert resolved")
-
-				delete(firing, key)
-			}
+// Events returns the events recorded by the steps evaluated so far.
+func (e *Evaluator) Events() []Event {
+	return e.tracker.events
+}
+
+func (t *tracker) observePending(key string, alert *rules.Alert) {
+	if _, ok := t.pending[key]; ok {
+		return
+	}
+
+	t.pending[key] = &pendingAlert{
+		labels:   alert.Labels.Map(),
+		activeAt: alert.ActiveAt,
+	}
+}
+
+func (t *tracker) observeFiring(key string, alert *rules.Alert) {
+	delete(t.pending, key)
+
+	if fa, ok := t.firing[key]; ok {
+		fa.keepFiringSince = alert.KeepFiringSince
+		if alert.KeepFiringSince.IsZero() {
+			fa.values.observe(alert.Value)
+		}
+		return
+	}
+
+	var (
+		lbls   = alert.Labels.Map()
+		values = &Values{Firing: alert.Value, Peak: alert.Value, Min: alert.Value, Last: alert.Value}
+	)
+
+	t.firing[key] = &firingAlert{
+		labels:  lbls,
+		firedAt: alert.FiredAt,
+		values:  values,
+	}
+
+	var annotations map[string]string
+	if !alert.Annotations.IsEmpty() {
+		annotations = alert.Annotations.Map()
+	}
+
+	t.events = append(t.events, Event{
+		Time:        alert.FiredAt,
+		Labels:      lbls,
+		Type:        EventOpened,
+		ActiveAt:    alert.ActiveAt,
+		Annotations: annotations,
+		Values:      values,
+	})
+
+	zlog.Debug().
+		Time("firedAt", alert.FiredAt).
+		Time("activeAt", alert.ActiveAt).
+		Interface("labels", lbls).
+		Msg("alert opened")
+}
+
+// resolve emits events for alerts that were firing or pending before ts but
+// no longer are.
+func (t *tracker) resolve(ts time.Time, currentlyFiring, currentlyPending map[string]struct{}) {
+	for key, alert := range t.firing {
+		if _, stillFiring := currentlyFiring[key]; stillFiring {
+			continue
 		}
+
+		t.events = append(t.events, Event{
+			Time:            ts,
+			Labels:          alert.labels,
+			Type:            EventResolved,
+			KeepFiringSince: alert.keepFiringSince,
+		})
+
+		zlog.Debug().
+			Time("resolvedAt", ts).
+			Interface("labels", alert.labels).
+			Msg("alert resolved")
+
+		delete(t.firing, key)
 	}
 
-	return events, nil
+	for key, alert := range t.pending {
+		if _, stillPending := currentlyPending[key]; stillPending {
+			continue
+		}
+
+		t.events = append(t.events, Event{
+			Time:     ts,
+			Labels:   alert.labels,
+			Type:     EventNearMiss,
+			ActiveAt: alert.activeAt,
+		})
+
+		zlog.Debug().
+			Time("activeAt", alert.activeAt).
+			Time("clearedAt", ts).
+			Interface("labels", alert.labels).
+			Msg("pending alert cleared before firing")
+
+		delete(t.pending, key)
+	}
 }
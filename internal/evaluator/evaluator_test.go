@@ -5,7 +5,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/rulefmt"
 	"github.com/prometheus/prometheus/promql"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -41,7 +43,11 @@ func TestNew(t *testing.T) {
 		},
 	} {
 		t.Run(tt.name, func(t *testing.T) {
-			eval, err := New(tt.alertName, tt.expr, tt.forDuration)
+			eval, err := New(rulefmt.Rule{
+				Alert: tt.alertName,
+				Expr:  tt.expr,
+				For:   model.Duration(tt.forDuration),
+			})
 			if tt.wantErr {
 				require.Error(t, err)
 				assert.Nil(t, eval)
@@ -54,7 +60,7 @@ func TestNew(t *testing.T) {
 }
 
 func TestEvaluate_noData(t *testing.T) {
-	eval, err := New("TestAlert", `up == 0`, 0)
+	eval, err := New(rulefmt.Rule{Alert: "TestAlert", Expr: `up == 0`})
 	require.NoError(t, err)
 
 	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
@@ -66,7 +72,7 @@ func TestEvaluate_noData(t *testing.T) {
 }
 
 func TestEvaluate_firingThenResolved(t *testing.T) {
-	eval, err := New("TestAlert", `up == 0`, 0)
+	eval, err := New(rulefmt.Rule{Alert: "TestAlert", Expr: `up == 0`})
 	require.NoError(t, err)
 
 	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
@@ -97,7 +103,7 @@ func TestEvaluate_firingThenResolved(t *testing.T) {
 }
 
 func TestEvaluate_unresolved(t *testing.T) {
-	eval, err := New("TestAlert", `up == 0`, 0)
+	eval, err := New(rulefmt.Rule{Alert: "TestAlert", Expr: `up == 0`})
 	require.NoError(t, err)
 
 	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
@@ -120,10 +126,188 @@ func TestEvaluate_unresolved(t *testing.T) {
 }
 
 func TestEvaluate_emptyTimestamps(t *testing.T) {
-	eval, err := New("TestAlert", `up == 0`, 0)
+	eval, err := New(rulefmt.Rule{Alert: "TestAlert", Expr: `up == 0`})
 	require.NoError(t, err)
 
 	events, err := eval.Evaluate(context.Background(), prometheus.CachedQueryFunc(nil), nil)
 	require.NoError(t, err)
 	assert.Empty(t, events)
 }
+
+func TestEvaluate_keepFiringFor(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	step := 30 * time.Second
+
+	eval, err := New(rulefmt.Rule{
+		Alert:         "TestAlert",
+		Expr:          `up == 0`,
+		KeepFiringFor: model.Duration(2 * step),
+	})
+	require.NoError(t, err)
+
+	metric := labels.FromStrings("__name__", "up", "alertname", "TestAlert", "job", "node")
+
+	cache := map[int64]promql.Vector{
+		base.UnixMilli():           {{T: base.UnixMilli(), F: 0, Metric: metric}},
+		base.Add(step).UnixMilli(): {{T: base.Add(step).UnixMilli(), F: 0, Metric: metric}},
+	}
+
+	timestamps := []time.Time{
+		base,
+		base.Add(step),
+		base.Add(2 * step),
+		base.Add(3 * step),
+		base.Add(4 * step),
+		base.Add(5 * step),
+	}
+
+	events, err := eval.Evaluate(context.Background(), prometheus.CachedQueryFunc(cache), timestamps)
+	require.NoError(t, err)
+
+	require.Len(t, events, 2)
+	assert.Equal(t, EventOpened, events[0].Type)
+	assert.Equal(t, EventResolved, events[1].Type)
+	assert.Equal(t, base.Add(4*step), events[1].Time)
+	assert.Equal(t, base.Add(2*step), events[1].KeepFiringSince)
+}
+
+func TestEvaluate_labelsAndAnnotations(t *testing.T) {
+	eval, err := New(rulefmt.Rule{
+		Alert: "TestAlert",
+		Expr:  `up == 0`,
+		Labels: map[string]string{
+			"severity": "critical",
+			"target":   "{{ $labels.job }}",
+		},
+		Annotations: map[string]string{
+			"summary": "{{ $labels.job }} is down (value {{ $value }})",
+		},
+	})
+	require.NoError(t, err)
+
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	metric := labels.FromStrings("__name__", "up", "job", "node")
+
+	cache := map[int64]promql.Vector{
+		base.UnixMilli(): {{T: base.UnixMilli(), F: 0, Metric: metric}},
+	}
+
+	events, err := eval.Evaluate(context.Background(), prometheus.CachedQueryFunc(cache), []time.Time{base})
+	require.NoError(t, err)
+
+	require.Len(t, events, 1)
+	assert.Equal(t, map[string]string{
+		"alertname": "TestAlert",
+		"job":       "node",
+		"severity":  "critical",
+		"target":    "node",
+	}, events[0].Labels)
+	assert.Equal(t, map[string]string{"summary": "node is down (value 0)"}, events[0].Annotations)
+}
+
+func TestEvaluate_pendingAndNearMiss(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	step := 30 * time.Second
+
+	eval, err := New(rulefmt.Rule{
+		Alert: "TestAlert",
+		Expr:  `up == 0`,
+		For:   model.Duration(2 * step),
+	})
+	require.NoError(t, err)
+
+	metric := labels.FromStrings("__name__", "up", "job", "node")
+
+	// Down for two evaluations (not long enough to fire), then down for
+	// four evaluations which fires after the third.
+	cache := make(map[int64]promql.Vector)
+	for _, i := range []int{0, 1, 3, 4, 5, 6} {
+		ts := base.Add(time.Duration(i) * step).UnixMilli()
+		cache[ts] = promql.Vector{{T: ts, F: 0, Metric: metric}}
+	}
+
+	var timestamps []time.Time
+	for i := range 7 {
+		timestamps = append(timestamps, base.Add(time.Duration(i)*step))
+	}
+
+	events, err := eval.Evaluate(context.Background(), prometheus.CachedQueryFunc(cache), timestamps)
+	require.NoError(t, err)
+
+	require.Len(t, events, 2)
+
+	assert.Equal(t, EventNearMiss, events[0].Type)
+	assert.Equal(t, base, events[0].ActiveAt)
+	assert.Equal(t, base.Add(2*step), events[0].Time)
+
+	assert.Equal(t, EventOpened, events[1].Type)
+	assert.Equal(t, base.Add(3*step), events[1].ActiveAt)
+	assert.Equal(t, base.Add(5*step), events[1].Time)
+}
+
+func TestEvaluate_values(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	step := 30 * time.Second
+
+	eval, err := New(rulefmt.Rule{
+		Alert:         "TestAlert",
+		Expr:          `errors > 1`,
+		KeepFiringFor: model.Duration(2 * step),
+	})
+	require.NoError(t, err)
+
+	metric := labels.FromStrings("__name__", "errors", "job", "api")
+
+	cache := make(map[int64]promql.Vector)
+	for i, v := range []float64{5, 9, 2} {
+		ts := base.Add(time.Duration(i) * step).UnixMilli()
+		cache[ts] = promql.Vector{{T: ts, F: v, Metric: metric}}
+	}
+
+	var timestamps []time.Time
+	for i := range 6 {
+		timestamps = append(timestamps, base.Add(time.Duration(i)*step))
+	}
+
+	events, err := eval.Evaluate(context.Background(), prometheus.CachedQueryFunc(cache), timestamps)
+	require.NoError(t, err)
+
+	require.Len(t, events, 2)
+	require.Equal(t, EventOpened, events[0].Type)
+	assert.Equal(t, &Values{Firing: 5, Peak: 9, Min: 2, Last: 2}, events[0].Values)
+}
+
+func TestStep_alertsSeries(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	step := 30 * time.Second
+
+	eval, err := New(rulefmt.Rule{Alert: "TestAlert", Expr: `up == 0`, For: model.Duration(step)})
+	require.NoError(t, err)
+
+	metric := labels.FromStrings("__name__", "up", "job", "api")
+	cache := map[int64]promql.Vector{
+		base.UnixMilli():           {{T: base.UnixMilli(), F: 0, Metric: metric}},
+		base.Add(step).UnixMilli(): {{T: base.Add(step).UnixMilli(), F: 0, Metric: metric}},
+	}
+
+	alertState := func(vector promql.Vector) []string {
+		var states []string
+		for _, sample := range vector {
+			if sample.Metric.Get("__name__") == "ALERTS" {
+				states = append(states, sample.Metric.Get("alertstate"))
+			}
+		}
+		return states
+	}
+
+	vector, err := eval.Step(context.Background(), prometheus.CachedQueryFunc(cache), base)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pending"}, alertState(vector))
+	assert.Empty(t, eval.Events())
+
+	vector, err = eval.Step(context.Background(), prometheus.CachedQueryFunc(cache), base.Add(step))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"firing"}, alertState(vector))
+	require.Len(t, eval.Events(), 1)
+	assert.Equal(t, EventOpened, eval.Events()[0].Type)
+}
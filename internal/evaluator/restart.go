@@ -0,0 +1,124 @@
+package evaluator
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/rules"
+	zlog "github.com/rs/zerolog/log"
+
+	"github.com/steved/alertreplay/internal/prometheus"
+)
+
+// restoreAfterEvaluations is the number of evaluations after a restart before
+// Prometheus restores the 'for' state of alerts.
+const restoreAfterEvaluations = 2
+
+// Restarts describes restarts of the rule engine to simulate during a replay.
+type Restarts struct {
+	// Times are when the rule engine restarted. The rule's state is lost at
+	// the first evaluation at or after each of them.
+	Times []time.Time
+	// Restore restores the 'for' state of active alerts from the
+	// ALERTS_FOR_STATE series written before the restart, as Prometheus does.
+	// Otherwise alerts start pending again, as in vmalert without remote read.
+	Restore bool
+	// ForGracePeriod and OutageTolerance are Prometheus'
+	// --rules.alert.for-grace-period and --rules.alert.for-outage-tolerance.
+	ForGracePeriod  time.Duration
+	OutageTolerance time.Duration
+}
+
+type restartState struct {
+	Restarts
+	next int
+	// untilRestore counts down the evaluations after a restart until the
+	// 'for' state is restored.
+	untilRestore int
+	// store holds the series written by the rule, to restore from.
+	store   *prometheus.MemoryStorage
+	written map[string]labels.Labels
+}
+
+// SimulateRestarts makes the evaluator lose the rule's state at each restart
+// time and optionally restore it as Prometheus does. It must be called before
+// the first Step.
+func (e *Evaluator) SimulateRestarts(r Restarts) {
+	if len(r.Times) == 0 {
+		return
+	}
+
+	r.Times = slices.SortedFunc(slices.Values(r.Times), time.Time.Compare)
+
+	e.restart = &restartState{Restarts: r, store: prometheus.NewMemoryStorage()}
+}
+
+// restartIfDue replaces the rule with a new one, as a restarted rule engine
+// would start with, when a restart happened since the previous evaluation.
+func (e *Evaluator) restartIfDue(ts time.Time) {
+	rs := e.restart
+	if rs == nil {
+		return
+	}
+
+	due := false
+	for rs.next < len(rs.Times) && !rs.Times[rs.next].After(ts) {
+		rs.next++
+		due = true
+	}
+
+	if !due {
+		return
+	}
+
+	zlog.Debug().Time("at", ts).Bool("restore", rs.Restore).Msg("simulating rule engine restart")
+
+	// An unrestored rule doesn't write ALERTS or ALERTS_FOR_STATE until its
+	// state is restored.
+	e.rule = e.newRule(!rs.Restore)
+	rs.written = nil
+
+	if rs.Restore {
+		rs.untilRestore = restoreAfterEvaluations
+	}
+}
+
+// restoreIfDue records the series the rule wrote at ts and restores the 'for'
+// state once enough evaluations passed since a restart.
+func (e *Evaluator) restoreIfDue(ctx context.Context, ts time.Time, vector promql.Vector) {
+	rs := e.restart
+	if rs == nil || !rs.Restore {
+		return
+	}
+
+	if e.rule.Restored() {
+		rs.written = rs.store.Write(rs.written, ts.UnixMilli(), vector)
+	}
+
+	if rs.untilRestore == 0 {
+		return
+	}
+
+	rs.untilRestore--
+	if rs.untilRestore > 0 {
+		return
+	}
+
+	group := rules.NewGroup(rules.GroupOptions{
+		Name:          e.rule.Name(),
+		Rules:         []rules.Rule{e.rule},
+		ShouldRestore: true,
+		Opts: &rules.ManagerOptions{
+			Context:         ctx,
+			Queryable:       rs.store,
+			ForGracePeriod:  rs.ForGracePeriod,
+			OutageTolerance: rs.OutageTolerance,
+		},
+	})
+	group.RestoreForState(ts)
+
+	zlog.Debug().Time("at", ts).Msg("restored 'for' state")
+}
@@ -0,0 +1,119 @@
+package evaluator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/steved/alertreplay/internal/prometheus"
+)
+
+func TestEvaluate_restarts(t *testing.T) {
+	var (
+		base = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		at   = func(minutes int) time.Time { return base.Add(time.Duration(minutes) * time.Minute) }
+	)
+
+	// The condition is true for the whole hour.
+	metric := labels.FromStrings("__name__", "up", "job", "api")
+	cache := make(map[int64]promql.Vector)
+
+	var timestamps []time.Time
+	for i := range 60 {
+		cache[at(i).UnixMilli()] = promql.Vector{{T: at(i).UnixMilli(), F: 0, Metric: metric}}
+		timestamps = append(timestamps, at(i))
+	}
+
+	for _, tt := range []struct {
+		name         string
+		forMinutes   int
+		restarts     Restarts
+		wantOpened   []time.Time
+		wantResolved []time.Time
+	}{
+		{
+			name:       "no restarts",
+			forMinutes: 20,
+			wantOpened: []time.Time{at(20)},
+		},
+		{
+			name:       "pending state lost",
+			forMinutes: 20,
+			restarts:   Restarts{Times: []time.Time{at(10)}},
+			wantOpened: []time.Time{at(30)},
+		},
+		{
+			name:       "pending state restored, shifted by the downtime",
+			forMinutes: 20,
+			restarts:   Restarts{Times: []time.Time{at(10)}, Restore: true, ForGracePeriod: 10 * time.Minute, OutageTolerance: time.Hour},
+			// Restored after the second evaluation at 11m, 2m after the last
+			// ALERTS_FOR_STATE sample at 9m.
+			wantOpened: []time.Time{at(22)},
+		},
+		{
+			name:       "pending state restored within the grace period",
+			forMinutes: 20,
+			restarts:   Restarts{Times: []time.Time{at(10)}, Restore: true, ForGracePeriod: 15 * time.Minute, OutageTolerance: time.Hour},
+			wantOpened: []time.Time{at(26)},
+		},
+		{
+			name:       "pending state beyond the outage tolerance",
+			forMinutes: 20,
+			restarts:   Restarts{Times: []time.Time{at(10)}, Restore: true, ForGracePeriod: 10 * time.Minute, OutageTolerance: time.Minute},
+			wantOpened: []time.Time{at(30)},
+		},
+		{
+			name:       "for shorter than the grace period is not restored",
+			forMinutes: 5,
+			restarts:   Restarts{Times: []time.Time{at(3)}, Restore: true, ForGracePeriod: 10 * time.Minute, OutageTolerance: time.Hour},
+			wantOpened: []time.Time{at(8)},
+		},
+		{
+			name:       "firing state restored",
+			forMinutes: 20,
+			restarts:   Restarts{Times: []time.Time{at(30)}, Restore: true, ForGracePeriod: 10 * time.Minute, OutageTolerance: time.Hour},
+			// The alert keeps firing through the evaluations before its state
+			// is restored.
+			wantOpened: []time.Time{at(20)},
+		},
+		{
+			name:       "firing state beyond the outage tolerance",
+			forMinutes: 20,
+			restarts:   Restarts{Times: []time.Time{at(30)}, Restore: true, ForGracePeriod: 10 * time.Minute, OutageTolerance: time.Minute},
+			// Not restored at 31m, so it resolves once it is pending again at
+			// 32m and fires 20m after the restart.
+			wantOpened:   []time.Time{at(20), at(50)},
+			wantResolved: []time.Time{at(32)},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			eval, err := New(rulefmt.Rule{Alert: "TestAlert", Expr: `up == 0`, For: model.Duration(time.Duration(tt.forMinutes) * time.Minute)})
+			require.NoError(t, err)
+
+			eval.SimulateRestarts(tt.restarts)
+
+			events, err := eval.Evaluate(context.Background(), prometheus.CachedQueryFunc(cache), timestamps)
+			require.NoError(t, err)
+
+			var opened, resolved []time.Time
+			for _, event := range events {
+				switch event.Type {
+				case EventOpened:
+					opened = append(opened, event.Time)
+				case EventResolved:
+					resolved = append(resolved, event.Time)
+				}
+			}
+
+			assert.Equal(t, tt.wantOpened, opened)
+			assert.Equal(t, tt.wantResolved, resolved)
+		})
+	}
+}
@@ -0,0 +1,329 @@
+// Package explain evaluates the sub-expressions of an alert rule at a single
+// point in time, to show why the alert did or didn't fire.
+package explain
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+
+	"github.com/steved/alertreplay/internal/prometheus"
+)
+
+// Explanation holds the values of an alert expression and its sub-expressions
+// at At, and the series the expression returned and the outcome of its
+// comparisons over the for window ending at At.
+type Explanation struct {
+	Alert string
+	At    time.Time
+	For   time.Duration
+	// Nodes are the sub-expressions of the alert expression, in the order they
+	// appear in it, starting with the expression itself.
+	Nodes []Node
+	// Evaluations is the number of rule evaluations in the for window.
+	Evaluations int
+	// Window has the series returned by the expression in the for window.
+	Window []Series
+}
+
+// Node is a sub-expression and its samples at the explained time.
+type Node struct {
+	Expr string
+	// Depth is how deeply Expr is nested in the alert expression.
+	Depth   int
+	Samples promql.Vector
+	// Comparisons pair the series of both sides of Expr when it is a
+	// comparison.
+	Comparisons []Comparison
+	// Steps are the comparisons at each evaluation of the for window when
+	// Expr is a comparison.
+	Steps []Step
+}
+
+// Step is the outcome of a comparison at one evaluation of the for window.
+type Step struct {
+	At          time.Time
+	Comparisons []Comparison
+}
+
+// Comparison is the comparison of a series from one side of a comparison
+// operator with its match on the other side.
+type Comparison struct {
+	Op     string
+	Labels labels.Labels
+	Left   float64
+	Right  float64
+	// Matched is false when no series on the other side matched, in which
+	// case the series is dropped whatever its value.
+	Matched bool
+	// Passed reports whether the series is kept by the comparison.
+	Passed bool
+}
+
+// Margin is how far the left value is above the right one.
+func (c Comparison) Margin() float64 {
+	return c.Left - c.Right
+}
+
+// Series is a series returned by the alert expression in the for window.
+type Series struct {
+	Labels labels.Labels
+	// Active is the number of evaluations that returned the series.
+	Active int
+}
+
+// Explain evaluates the expression of r and each of its sub-expressions at at,
+// and the expression and its comparisons over the for window ending at at. at
+// is rounded down to the evaluation at or before it, so that the window holds
+// the evaluations the rule engine would have made.
+func Explain(ctx context.Context, client prometheus.Client, r rulefmt.Rule, at time.Time, interval time.Duration) (Explanation, error) {
+	expr, err := parser.ParseExpr(r.Expr)
+	if err != nil {
+		return Explanation{}, fmt.Errorf("parsing expression: %w", err)
+	}
+
+	at = prometheus.AlignToStep(at, interval)
+	e := Explanation{Alert: r.Alert, At: at, For: time.Duration(r.For)}
+
+	nodes := subExpressions(expr)
+	for _, n := range nodes {
+		samples, err := client.Query(ctx, n.expr.String(), at)
+		if err != nil {
+			return Explanation{}, fmt.Errorf("evaluating %s: %w", n.expr, err)
+		}
+
+		e.Nodes = append(e.Nodes, Node{Expr: n.expr.String(), Depth: n.depth, Samples: samples})
+	}
+
+	for i, n := range nodes {
+		if be, ok := n.expr.(*parser.BinaryExpr); ok && be.Op.IsComparisonOperator() {
+			e.Nodes[i].Comparisons = compare(be, e.Nodes[n.lhs].Samples, e.Nodes[n.rhs].Samples)
+		}
+	}
+
+	ranged := make(map[string]map[int64]promql.Vector)
+	queryWindow := func(expr string) (map[int64]promql.Vector, []time.Time, error) {
+		vectors, timestamps, err := client.QueryExpr(ctx, expr, at.Add(-e.For), at, interval)
+		if err != nil {
+			return nil, nil, fmt.Errorf("evaluating %s over the for window: %w", expr, err)
+		}
+
+		ranged[expr] = vectors
+
+		return vectors, timestamps, nil
+	}
+
+	vectors, timestamps, err := queryWindow(expr.String())
+	if err != nil {
+		return Explanation{}, err
+	}
+
+	e.Evaluations = len(timestamps)
+
+	window := make(map[string]*Series)
+	for _, ts := range timestamps {
+		for _, sample := range vectors[ts.UnixMilli()] {
+			key := sample.Metric.String()
+			if _, ok := window[key]; !ok {
+				window[key] = &Series{Labels: sample.Metric}
+			}
+			window[key].Active++
+		}
+	}
+
+	for _, s := range window {
+		e.Window = append(e.Window, *s)
+	}
+
+	slices.SortFunc(e.Window, func(a, b Series) int { return labels.Compare(a.Labels, b.Labels) })
+
+	for i, n := range nodes {
+		be, ok := n.expr.(*parser.BinaryExpr)
+		if !ok || !be.Op.IsComparisonOperator() {
+			continue
+		}
+
+		sides := make([]map[int64]promql.Vector, 0, 2)
+		for _, side := range []int{n.lhs, n.rhs} {
+			vectors, ok := ranged[e.Nodes[side].Expr]
+			if !ok {
+				if vectors, _, err = queryWindow(e.Nodes[side].Expr); err != nil {
+					return Explanation{}, err
+				}
+			}
+
+			sides = append(sides, vectors)
+		}
+
+		for _, ts := range timestamps {
+			e.Nodes[i].Steps = append(e.Nodes[i].Steps, Step{
+				At:          ts,
+				Comparisons: compare(be, sides[0][ts.UnixMilli()], sides[1][ts.UnixMilli()]),
+			})
+		}
+	}
+
+	return e, nil
+}
+
+type subExpression struct {
+	expr  parser.Expr
+	depth int
+	// lhs and rhs index the sides of a binary expression.
+	lhs, rhs int
+}
+
+// subExpressions returns expr, the sides of its binary operations and its
+// series selectors, each nested one level below the closest of them it is
+// part of.
+func subExpressions(expr parser.Expr) []subExpression {
+	var nodes []subExpression
+
+	var walk func(node parser.Node, depth int, side bool)
+	walk = func(node parser.Node, depth int, side bool) {
+		if e, ok := node.(parser.Expr); ok {
+			node = unwrap(e)
+		}
+
+		switch node.(type) {
+		case *parser.BinaryExpr, *parser.VectorSelector:
+			side = true
+		case *parser.StringLiteral:
+			return
+		}
+
+		if !side {
+			for _, child := range parser.Children(node) {
+				if _, ok := child.(*parser.NumberLiteral); !ok {
+					walk(child, depth, false)
+				}
+			}
+			return
+		}
+
+		i := len(nodes)
+		nodes = append(nodes, subExpression{expr: node.(parser.Expr), depth: depth})
+
+		if be, ok := node.(*parser.BinaryExpr); ok {
+			nodes[i].lhs = len(nodes)
+			walk(be.LHS, depth+1, true)
+			nodes[i].rhs = len(nodes)
+			walk(be.RHS, depth+1, true)
+			return
+		}
+
+		for _, child := range parser.Children(node) {
+			if _, ok := child.(*parser.NumberLiteral); !ok {
+				walk(child, depth+1, false)
+			}
+		}
+	}
+
+	walk(expr, 0, true)
+
+	return nodes
+}
+
+func unwrap(e parser.Expr) parser.Expr {
+	for {
+		p, ok := e.(*parser.ParenExpr)
+		if !ok {
+			return e
+		}
+		e = p.Expr
+	}
+}
+
+// compare pairs the samples of both sides of a comparison the way Prometheus
+// matches them: every sample with a scalar side, or by their labels as
+// configured by on, ignoring and group modifiers.
+func compare(be *parser.BinaryExpr, lhs, rhs promql.Vector) []Comparison {
+	var comparisons []Comparison
+
+	add := func(ls labels.Labels, left, right float64, matched bool) {
+		comparisons = append(comparisons, Comparison{
+			Op:      be.Op.String(),
+			Labels:  ls,
+			Left:    left,
+			Right:   right,
+			Matched: matched,
+			Passed:  matched && compareValues(be.Op, left, right),
+		})
+	}
+
+	switch {
+	case be.RHS.Type() == parser.ValueTypeScalar:
+		for _, l := range lhs {
+			if len(rhs) == 0 {
+				add(l.Metric, l.F, 0, false)
+				continue
+			}
+			add(l.Metric, l.F, rhs[0].F, true)
+		}
+	case be.LHS.Type() == parser.ValueTypeScalar:
+		for _, r := range rhs {
+			if len(lhs) == 0 {
+				add(r.Metric, 0, r.F, false)
+				continue
+			}
+			add(r.Metric, lhs[0].F, r.F, true)
+		}
+	default:
+		vm := be.VectorMatching
+		if vm == nil {
+			vm = &parser.VectorMatching{}
+		}
+
+		signature := func(ls labels.Labels) string {
+			return ls.MatchLabels(vm.On, vm.MatchingLabels...).String()
+		}
+
+		// Each sample of the side with the higher cardinality is compared with
+		// the matching sample of the other side.
+		many, one := lhs, rhs
+		if vm.Card == parser.CardOneToMany {
+			many, one = rhs, lhs
+		}
+
+		matches := make(map[string]float64, len(one))
+		for _, s := range one {
+			matches[signature(s.Metric)] = s.F
+		}
+
+		for _, s := range many {
+			other, ok := matches[signature(s.Metric)]
+			if vm.Card == parser.CardOneToMany {
+				add(s.Metric, other, s.F, ok)
+			} else {
+				add(s.Metric, s.F, other, ok)
+			}
+		}
+	}
+
+	return comparisons
+}
+
+func compareValues(op parser.ItemType, left, right float64) bool {
+	switch op {
+	case parser.EQLC:
+		return left == right
+	case parser.NEQ:
+		return left != right
+	case parser.GTR:
+		return left > right
+	case parser.LSS:
+		return left < right
+	case parser.GTE:
+		return left >= right
+	case parser.LTE:
+		return left <= right
+	default:
+		return false
+	}
+}
@@ -0,0 +1,225 @@
+package explain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/steved/alertreplay/internal/prometheus"
+)
+
+// fakeClient returns canned instant and range query results per expression.
+type fakeClient struct {
+	prometheus.Client
+	instant map[string]promql.Vector
+	ranged  map[string]map[int64]promql.Vector
+}
+
+func (f *fakeClient) Query(_ context.Context, expr string, _ time.Time) (promql.Vector, error) {
+	return f.instant[expr], nil
+}
+
+func (f *fakeClient) QueryExpr(
+	_ context.Context,
+	expr string,
+	from time.Time,
+	to time.Time,
+	interval time.Duration,
+) (map[int64]promql.Vector, []time.Time, error) {
+	return f.ranged[expr], prometheus.Timestamps(from, to, interval), nil
+}
+
+func sample(v float64, ls ...string) promql.Sample {
+	return promql.Sample{F: v, Metric: labels.FromStrings(ls...)}
+}
+
+func TestExplain(t *testing.T) {
+	var (
+		at   = time.Date(2026, 1, 3, 4, 5, 0, 0, time.UTC)
+		step = time.Minute
+		expr = `sum by (job) (rate(errors[5m])) / sum by (job) (rate(requests[5m])) > 0.05`
+	)
+
+	client := &fakeClient{
+		instant: map[string]promql.Vector{
+			expr: {sample(0.1, "job", "api")},
+			`sum by (job) (rate(errors[5m])) / sum by (job) (rate(requests[5m]))`: {
+				sample(0.1, "job", "api"),
+				sample(0.01, "job", "db"),
+			},
+			`sum by (job) (rate(errors[5m]))`:   {sample(1, "job", "api"), sample(0.1, "job", "db")},
+			`errors`:                            {sample(60, "__name__", "errors", "job", "api")},
+			`sum by (job) (rate(requests[5m]))`: {sample(10, "job", "api"), sample(10, "job", "db")},
+			`requests`:                          {sample(600, "__name__", "requests", "job", "api")},
+			`0.05`:                              {sample(0.05)},
+		},
+		ranged: map[string]map[int64]promql.Vector{
+			expr: {
+				at.Add(-2 * step).UnixMilli(): {sample(0.1, "job", "api"), sample(0.2, "job", "web")},
+				at.Add(-step).UnixMilli():     {sample(0.1, "job", "api")},
+				at.UnixMilli():                {sample(0.1, "job", "api")},
+			},
+			`sum by (job) (rate(errors[5m])) / sum by (job) (rate(requests[5m]))`: {
+				at.Add(-2 * step).UnixMilli(): {sample(0.1, "job", "api"), sample(0.2, "job", "web")},
+				at.Add(-step).UnixMilli():     {sample(0.1, "job", "api"), sample(0.01, "job", "web")},
+				at.UnixMilli():                {sample(0.1, "job", "api")},
+			},
+			`0.05`: {
+				at.Add(-2 * step).UnixMilli(): {sample(0.05)},
+				at.Add(-step).UnixMilli():     {sample(0.05)},
+				at.UnixMilli():                {sample(0.05)},
+			},
+		},
+	}
+
+	rule := rulefmt.Rule{Alert: "HighErrorRate", Expr: expr, For: model.Duration(2 * step)}
+
+	e, err := Explain(t.Context(), client, rule, at, step)
+	require.NoError(t, err)
+
+	assert.Equal(t, "HighErrorRate", e.Alert)
+	assert.Equal(t, 2*step, e.For)
+
+	var (
+		exprs  []string
+		depths []int
+	)
+	for _, n := range e.Nodes {
+		exprs = append(exprs, n.Expr)
+		depths = append(depths, n.Depth)
+	}
+
+	assert.Equal(t, []string{
+		expr,
+		`sum by (job) (rate(errors[5m])) / sum by (job) (rate(requests[5m]))`,
+		`sum by (job) (rate(errors[5m]))`,
+		`errors`,
+		`sum by (job) (rate(requests[5m]))`,
+		`requests`,
+		`0.05`,
+	}, exprs)
+	assert.Equal(t, []int{0, 1, 2, 3, 2, 3, 1}, depths)
+
+	assert.Equal(t, []Comparison{
+		{Op: ">", Labels: labels.FromStrings("job", "api"), Left: 0.1, Right: 0.05, Matched: true, Passed: true},
+		{Op: ">", Labels: labels.FromStrings("job", "db"), Left: 0.01, Right: 0.05, Matched: true},
+	}, e.Nodes[0].Comparisons)
+	assert.InDelta(t, -0.04, e.Nodes[0].Comparisons[1].Margin(), 1e-9)
+
+	for _, n := range e.Nodes[1:] {
+		assert.Empty(t, n.Comparisons, n.Expr)
+		assert.Empty(t, n.Steps, n.Expr)
+	}
+
+	assert.Equal(t, []Step{
+		{At: at.Add(-2 * step), Comparisons: []Comparison{
+			{Op: ">", Labels: labels.FromStrings("job", "api"), Left: 0.1, Right: 0.05, Matched: true, Passed: true},
+			{Op: ">", Labels: labels.FromStrings("job", "web"), Left: 0.2, Right: 0.05, Matched: true, Passed: true},
+		}},
+		{At: at.Add(-step), Comparisons: []Comparison{
+			{Op: ">", Labels: labels.FromStrings("job", "api"), Left: 0.1, Right: 0.05, Matched: true, Passed: true},
+			{Op: ">", Labels: labels.FromStrings("job", "web"), Left: 0.01, Right: 0.05, Matched: true},
+		}},
+		{At: at, Comparisons: []Comparison{
+			{Op: ">", Labels: labels.FromStrings("job", "api"), Left: 0.1, Right: 0.05, Matched: true, Passed: true},
+		}},
+	}, e.Nodes[0].Steps)
+
+	assert.Equal(t, 3, e.Evaluations)
+	assert.Equal(t, []Series{
+		{Labels: labels.FromStrings("job", "api"), Active: 3},
+		{Labels: labels.FromStrings("job", "web"), Active: 1},
+	}, e.Window)
+}
+
+func TestExplain_window(t *testing.T) {
+	var (
+		at   = time.Date(2026, 1, 3, 4, 5, 30, 0, time.UTC)
+		step = time.Minute
+	)
+
+	// The rule engine evaluated at 04:03, 04:04 and 04:05, and the alert only
+	// fires at 04:05 if the series was returned since 04:03, 2 minutes before,
+	// as 1 minute is shorter than the for duration.
+	e, err := Explain(t.Context(), &fakeClient{}, rulefmt.Rule{Expr: "up == 0", For: model.Duration(90 * time.Second)}, at, step)
+	require.NoError(t, err)
+
+	assert.Equal(t, time.Date(2026, 1, 3, 4, 5, 0, 0, time.UTC), e.At)
+	assert.Equal(t, 3, e.Evaluations)
+	assert.Len(t, e.Nodes[0].Steps, 3)
+}
+
+func TestExplain_invalidExpr(t *testing.T) {
+	_, err := Explain(t.Context(), &fakeClient{}, rulefmt.Rule{Expr: "up >"}, time.Now(), time.Minute)
+	require.ErrorContains(t, err, "parsing expression")
+}
+
+func TestCompare(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		expr     string
+		lhs, rhs promql.Vector
+		want     []Comparison
+	}{
+		{
+			name: "scalar on the left",
+			expr: `1 < up`,
+			lhs:  promql.Vector{sample(1)},
+			rhs:  promql.Vector{sample(0, "job", "api"), sample(1, "job", "db")},
+			want: []Comparison{
+				{Op: "<", Labels: labels.FromStrings("job", "api"), Left: 1, Right: 0, Matched: true},
+				{Op: "<", Labels: labels.FromStrings("job", "db"), Left: 1, Right: 1, Matched: true},
+			},
+		},
+		{
+			name: "vectors matched ignoring the metric name",
+			expr: `used >= limit`,
+			lhs: promql.Vector{
+				sample(10, "__name__", "used", "job", "api"),
+				sample(5, "__name__", "used", "job", "db"),
+			},
+			rhs: promql.Vector{sample(10, "__name__", "limit", "job", "api")},
+			want: []Comparison{
+				{Op: ">=", Labels: labels.FromStrings("__name__", "used", "job", "api"), Left: 10, Right: 10, Matched: true, Passed: true},
+				{Op: ">=", Labels: labels.FromStrings("__name__", "used", "job", "db"), Left: 5},
+			},
+		},
+		{
+			name: "on",
+			expr: `used > on (job) limit`,
+			lhs:  promql.Vector{sample(10, "job", "api", "instance", "a")},
+			rhs:  promql.Vector{sample(5, "job", "api", "instance", "b")},
+			want: []Comparison{
+				{Op: ">", Labels: labels.FromStrings("job", "api", "instance", "a"), Left: 10, Right: 5, Matched: true, Passed: true},
+			},
+		},
+		{
+			name: "group_right",
+			expr: `limit < on (job) group_right used`,
+			lhs:  promql.Vector{sample(5, "job", "api")},
+			rhs: promql.Vector{
+				sample(10, "job", "api", "instance", "a"),
+				sample(1, "job", "api", "instance", "b"),
+			},
+			want: []Comparison{
+				{Op: "<", Labels: labels.FromStrings("job", "api", "instance", "a"), Left: 5, Right: 10, Matched: true, Passed: true},
+				{Op: "<", Labels: labels.FromStrings("job", "api", "instance", "b"), Left: 5, Right: 1, Matched: true},
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := parser.ParseExpr(tt.expr)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, compare(expr.(*parser.BinaryExpr), tt.lhs, tt.rhs))
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package output
+
+import (
+	"encoding/csv"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+)
+
+func init() {
+	Register(FormatCSV, recordsWriter{render: func(w io.Writer, _ Run, records []Record, _ Reports) error {
+		return RenderCSV(w, records, ',')
+	}, alertsOnly: true})
+	Register(FormatTSV, recordsWriter{render: func(w io.Writer, _ Run, records []Record, _ Reports) error {
+		return RenderCSV(w, records, '\t')
+	}, alertsOnly: true})
+}
+
+// RenderCSV writes alert records to w as comma-separated values, or with
+// another separator such as a tab, one row per alert. Each label gets its own
+// column prefixed with label_, so labels such as url can't collide with the
+// other columns, empty for alerts without it. Source, diff, pending, keep
+// firing, value and suppression columns are only written when a record has
+// them. Near misses and notifications aren't written.
+func RenderCSV(w io.Writer, records []Record, comma rune) error {
+	labelSet := make(map[string]struct{})
+	for _, r := range records {
+		for name := range r.Labels {
+			if name != "alertname" && name != "__name__" {
+				labelSet[name] = struct{}{}
+			}
+		}
+	}
+	labelNames := slices.Sorted(maps.Keys(labelSet))
+
+	var (
+		hasSource     = slices.ContainsFunc(records, func(r Record) bool { return r.Source != "" })
+		hasDiff       = slices.ContainsFunc(records, func(r Record) bool { return r.Diff != "" })
+		hasPending    = slices.ContainsFunc(records, func(r Record) bool { return r.PendingAt != nil })
+		hasKeepFiring = slices.ContainsFunc(records, func(r Record) bool { return r.KeepFiringSince != nil })
+		hasValues     = slices.ContainsFunc(records, func(r Record) bool { return r.Values != nil })
+		hasSilenced   = slices.ContainsFunc(records, func(r Record) bool { return r.Silenced != nil })
+		hasInhibited  = slices.ContainsFunc(records, func(r Record) bool { return r.Inhibited != nil })
+	)
+
+	header := []string{"alertname"}
+	if hasSource {
+		header = append(header, "source")
+	}
+	if hasDiff {
+		header = append(header, "diff", "diff_row")
+	}
+	if hasPending {
+		header = append(header, "pending_at")
+	}
+	header = append(header, "opened_at", "resolved_at")
+	if hasKeepFiring {
+		header = append(header, "keep_firing_since")
+	}
+	header = append(header, "duration_seconds")
+	if hasValues {
+		header = append(header, "value_firing", "value_peak", "value_min", "value_last")
+	}
+	if hasSilenced {
+		header = append(header, "silenced_share")
+	}
+	if hasInhibited {
+		header = append(header, "inhibited_share")
+	}
+	for _, name := range labelNames {
+		header = append(header, "label_"+name)
+	}
+	header = append(header, "url")
+
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, r := range records {
+		row := []string{r.Alertname}
+		if hasSource {
+			row = append(row, r.Source)
+		}
+		if hasDiff {
+			diffRow := ""
+			if r.DiffRow != nil {
+				diffRow = strconv.Itoa(*r.DiffRow)
+			}
+			row = append(row, string(r.Diff), diffRow)
+		}
+
+		if hasPending {
+			row = append(row, csvTime(r.PendingAt))
+		}
+		row = append(row, r.OpenedAt, csvTime(r.ResolvedAt))
+		if hasKeepFiring {
+			row = append(row, csvTime(r.KeepFiringSince))
+		}
+		row = append(row, strconv.FormatFloat(r.DurationSeconds, 'f', -1, 64))
+
+		if hasValues {
+			if r.Values != nil {
+				row = append(row, csvFloat(r.Values.Firing), csvFloat(r.Values.Peak), csvFloat(r.Values.Min), csvFloat(r.Values.Last))
+			} else {
+				row = append(row, "", "", "", "")
+			}
+		}
+		if hasSilenced {
+			row = append(row, csvShare(r.Silenced))
+		}
+		if hasInhibited {
+			row = append(row, csvShare(r.Inhibited))
+		}
+
+		for _, name := range labelNames {
+			row = append(row, r.Labels[name])
+		}
+		row = append(row, r.URL)
+
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+func csvFloat(v Float) string {
+	return strconv.FormatFloat(float64(v), 'g', -1, 64)
+}
+
+func csvTime(t *string) string {
+	if t == nil {
+		return ""
+	}
+
+	return *t
+}
+
+func csvShare(s *RecordSuppression) string {
+	if s == nil {
+		return ""
+	}
+
+	return strconv.FormatFloat(s.Share, 'g', -1, 64)
+}
@@ -0,0 +1,100 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/steved/alertreplay/internal/alert"
+)
+
+func TestRenderCSV(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	alerts := []alert.Alert{
+		{
+			OpenedAt:   base,
+			ResolvedAt: new(base.Add(10 * time.Minute)),
+			Labels:     map[string]string{"alertname": "HighErrorRate", "job": "api", "instance": "a:9090"},
+			Values:     &alert.Values{Firing: 2, Peak: 5, Min: 1.5, Last: 1.5},
+			URL:        "http://localhost:9090/graph",
+		},
+		{
+			OpenedAt: base.Add(time.Hour),
+			Labels:   map[string]string{"alertname": "HighErrorRate", "job": "db, replica"},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, RenderCSV(&buf, AlertRecords(alerts, base.Add(90*time.Minute)), ','))
+
+	assert.Equal(t, `alertname,opened_at,resolved_at,duration_seconds,value_firing,value_peak,value_min,value_last,label_instance,label_job,url
+HighErrorRate,2026-01-01T12:00:00Z,2026-01-01T12:10:00Z,600,2,5,1.5,1.5,a:9090,api,http://localhost:9090/graph
+HighErrorRate,2026-01-01T13:00:00Z,,1800,,,,,,"db, replica",
+`, buf.String())
+}
+
+func TestRenderCSV_diff(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	left := []alert.Alert{{OpenedAt: base, ResolvedAt: new(base.Add(time.Minute)), Labels: map[string]string{"alertname": "A", "job": "api"}}}
+	right := []alert.Alert{{OpenedAt: base.Add(time.Hour), ResolvedAt: new(base.Add(2 * time.Hour)), Labels: map[string]string{"alertname": "A", "job": "db"}}}
+	rows := alert.Diff(left, right, alert.DefaultMatcher)
+
+	var buf bytes.Buffer
+	require.NoError(t, RenderCSV(&buf, DiffRecords("old.yaml", "new.yaml", rows, base, base), '\t'))
+
+	assert.Equal(t, "alertname\tsource\tdiff\tdiff_row\topened_at\tresolved_at\tduration_seconds\tlabel_job\turl\n"+
+		"A\told.yaml\tonly-left\t0\t2026-01-01T12:00:00Z\t2026-01-01T12:01:00Z\t60\tapi\t\n"+
+		"A\tnew.yaml\tonly-right\t1\t2026-01-01T13:00:00Z\t2026-01-01T14:00:00Z\t3600\tdb\t\n",
+		buf.String())
+}
+
+func TestRenderCSV_labelCollisions(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	alerts := []alert.Alert{{
+		OpenedAt: base,
+		Labels:   map[string]string{"alertname": "A", "url": "/api", "opened_at": "yesterday"},
+		URL:      "http://localhost:9090/graph",
+	}}
+
+	var buf bytes.Buffer
+	require.NoError(t, RenderCSV(&buf, AlertRecords(alerts, base.Add(time.Minute)), ','))
+
+	assert.Equal(t, `alertname,opened_at,resolved_at,duration_seconds,label_opened_at,label_url,url
+A,2026-01-01T12:00:00Z,,60,yesterday,/api,http://localhost:9090/graph
+`, buf.String())
+}
+
+func TestRenderCSV_reports(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	alerts := []alert.Alert{
+		{
+			PendingAt:       base.Add(-5 * time.Minute),
+			OpenedAt:        base,
+			ResolvedAt:      new(base.Add(20 * time.Minute)),
+			KeepFiringSince: new(base.Add(15 * time.Minute)),
+			Labels:          map[string]string{"alertname": "A"},
+			Silenced:        &alert.Suppression{Intervals: []alert.Interval{{Start: base, End: base.Add(5 * time.Minute)}}, Share: 0.25},
+		},
+		{
+			PendingAt: base.Add(time.Hour),
+			OpenedAt:  base.Add(time.Hour),
+			Labels:    map[string]string{"alertname": "A"},
+			Inhibited: &alert.Suppression{Intervals: []alert.Interval{{Start: base.Add(time.Hour), End: base.Add(90 * time.Minute)}}, Share: 1},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, RenderCSV(&buf, AlertRecords(alerts, base.Add(90*time.Minute)), ','))
+
+	assert.Equal(t, `alertname,pending_at,opened_at,resolved_at,keep_firing_since,duration_seconds,silenced_share,inhibited_share,url
+A,2026-01-01T11:55:00Z,2026-01-01T12:00:00Z,2026-01-01T12:20:00Z,2026-01-01T12:15:00Z,1200,0.25,,
+A,2026-01-01T13:00:00Z,2026-01-01T13:00:00Z,,,1800,,1,
+`, buf.String())
+}
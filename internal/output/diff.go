@@ -0,0 +1,423 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	zlog "github.com/rs/zerolog/log"
+
+	"github.com/steved/alertreplay/internal/alert"
+)
+
+// DiffStatus tells how an alert rule differs between two rule files.
+type DiffStatus string
+
+const (
+	DiffAdded     DiffStatus = "added"
+	DiffRemoved   DiffStatus = "removed"
+	DiffChanged   DiffStatus = "changed"
+	DiffUnchanged DiffStatus = "unchanged"
+)
+
+const (
+	colWidthStatus = 10
+	colWidthCount  = 12
+	colWidthMarker = 4
+)
+
+// diffMarkers prefix diff rows by kind. Matched rows are only marked when
+// their alerts opened or resolved at different times.
+var diffMarkers = map[alert.DiffKind]string{
+	alert.DiffMatched:   "~",
+	alert.DiffOnlyLeft:  "-",
+	alert.DiffOnlyRight: "+",
+}
+
+// DiffSummary compares the alerts replayed for an alert rule from two rule
+// files.
+type DiffSummary struct {
+	AlertName string
+	Status    DiffStatus
+	Count1    int
+	Count2    int
+	Matched   int
+	Only1     int
+	Only2     int
+	// FiringChange is the total firing time in the second file minus that in
+	// the first.
+	FiringChange time.Duration
+	// Rows is the detailed diff.
+	Rows []alert.DiffRow
+	// LeftSeries and RightSeries are the values of the alert expression with
+	// each file.
+	LeftSeries  []alert.Series
+	RightSeries []alert.Series
+}
+
+// WriteDiff shows the diff in an interactive table. With summaries, pressing
+// enter on a row shows the detailed diff of that rule.
+func (tableWriter) WriteDiff(w io.Writer, d DiffResult) error {
+	var err error
+	if d.Summaries != nil {
+		err = writeDiffSummariesTable(w, d.LeftName, d.RightName, d.Summaries)
+	} else {
+		err = writeDiffTable(w, d.LeftName, d.RightName, d.Rows)
+	}
+
+	if err != nil || !d.WithLabelSetChanges {
+		return err
+	}
+
+	return RenderLabelSetChanges(w, d.LeftName, d.RightName, d.LabelSetChanges)
+}
+
+// WriteDiff writes the diff as markdown. Summaries are followed by the
+// detailed diff of each rule.
+func (markdownWriter) WriteDiff(w io.Writer, d DiffResult) error {
+	switch {
+	case d.Summaries != nil && len(d.Summaries) == 0:
+		zlog.Info().Msg("No alert rules found.")
+	case d.Summaries != nil:
+		if err := RenderDiffSummaries(w, d.LeftName, d.RightName, d.Summaries); err != nil {
+			return err
+		}
+	case len(d.Rows) == 0:
+		zlog.Info().Msg("No alert events found.")
+	default:
+		if err := RenderDiff(w, d.LeftName, d.RightName, d.Rows); err != nil {
+			return err
+		}
+	}
+
+	if !d.WithLabelSetChanges {
+		return nil
+	}
+
+	return RenderLabelSetChanges(w, d.LeftName, d.RightName, d.LabelSetChanges)
+}
+
+func writeDiffSummariesTable(w io.Writer, name1, name2 string, summaries []DiffSummary) error {
+	if len(summaries) == 0 {
+		zlog.Info().Msg("No alert rules found.")
+		return nil
+	}
+
+	for {
+		termWidth, termHeight := terminalSize()
+
+		m := newDiffSummaryModel(name1, name2, summaries, termWidth, termHeight)
+		final, err := tea.NewProgram(m, tea.WithOutput(w)).Run()
+		if err != nil {
+			return fmt.Errorf("running table: %w", err)
+		}
+
+		selected := final.(tableModel).selected
+		if selected < 0 || selected >= len(summaries) {
+			return nil
+		}
+
+		if err := writeDiffTable(w, name1, name2, summaries[selected].Rows); err != nil {
+			return err
+		}
+	}
+}
+
+func newDiffSummaryModel(name1, name2 string, summaries []DiffSummary, termWidth, termHeight int) tableModel {
+	headers := diffSummaryHeaders(name1, name2)
+
+	columns := make([]table.Column, 0, len(headers))
+	for i, header := range headers {
+		width := colWidthCount
+		switch i {
+		case 0:
+			width = max(termWidth-baseStyle.GetHorizontalFrameSize()-len(headers)*2-colWidthStatus-colWidthCount*(len(headers)-2), minColWidthFlexible)
+		case 1:
+			width = colWidthStatus
+		}
+
+		columns = append(columns, table.Column{Title: header, Width: width})
+	}
+
+	rows := make([]table.Row, 0, len(summaries))
+	for _, s := range summaries {
+		rows = append(rows, diffSummaryRow(s.AlertName, s))
+	}
+
+	m := newModel(columns, rows, 0, termWidth, termHeight)
+	m.selectable = true
+	m.help = "Press enter to show the alert diff, ←/→ to scroll, q to quit"
+	m.refreshContent()
+
+	return m
+}
+
+// RenderDiffSummaries writes the summary table, linking each alert rule to its
+// detailed diff below it, as markdown to w.
+func RenderDiffSummaries(w io.Writer, name1, name2 string, summaries []DiffSummary) error {
+	t := newMarkdownTable(w, diffSummaryHeaders(name1, name2)...)
+	for _, s := range summaries {
+		t.Row(diffSummaryRow(fmt.Sprintf("[%s](#%s)", s.AlertName, markdownAnchor(s.AlertName)), s)...)
+	}
+
+	if _, err := fmt.Fprintln(w, t.Render()); err != nil {
+		return err
+	}
+
+	for _, s := range summaries {
+		if _, err := fmt.Fprintf(w, "\n### %s\n\n", s.AlertName); err != nil {
+			return err
+		}
+
+		if len(s.Rows) == 0 {
+			if _, err := fmt.Fprintln(w, "No alert events found."); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := RenderDiff(w, name1, name2, s.Rows); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeDiffTable shows the rows of a diff between two sets of alerts, named
+// leftName and rightName, in an interactive table.
+func writeDiffTable(w io.Writer, leftName, rightName string, rows []alert.DiffRow) error {
+	if len(rows) == 0 {
+		zlog.Info().Msg("No alert events found.")
+		return nil
+	}
+
+	termWidth, termHeight := terminalSize()
+
+	m := newDiffModel(leftName, rightName, rows, termWidth, termHeight)
+	if _, err := tea.NewProgram(m, tea.WithOutput(w)).Run(); err != nil {
+		return fmt.Errorf("running table: %w", err)
+	}
+
+	return nil
+}
+
+// newDiffModel creates a table of diff rows, coloured red when only in the
+// left side, green when only in the right one and yellow when their alerts
+// opened or resolved at different times.
+func newDiffModel(leftName, rightName string, rows []alert.DiffRow, termWidth, termHeight int) tableModel {
+	m := newItemsModel(rows, diffColumns(rows, leftName, rightName), alert.DiffRow.Alert, diffRowStyle, termWidth, termHeight)
+	m.help = fmt.Sprintf("- only in %s, + only in %s, ~ opened or resolved at a different time. Press enter to open UI, ←/→ to scroll, q to quit",
+		leftName, rightName)
+	m.refreshContent()
+
+	return m
+}
+
+func diffRowStyle(r alert.DiffRow) rowStyle {
+	switch {
+	case r.Kind == alert.DiffOnlyLeft:
+		return rowOnlyLeft
+	case r.Kind == alert.DiffOnlyRight:
+		return rowOnlyRight
+	case diffRowChanged(r):
+		return rowChanged
+	default:
+		return 0
+	}
+}
+
+// diffRowChanged tells whether the alerts of a matched row opened or resolved
+// at different times.
+func diffRowChanged(r alert.DiffRow) bool {
+	delta, ok := r.ResolvedDelta()
+
+	return r.Kind == alert.DiffMatched && (r.OpenedDelta() != 0 || !ok || delta != 0)
+}
+
+// RenderDiff writes the rows of a diff as a markdown table to w, each prefixed
+// with a marker of its kind.
+func RenderDiff(w io.Writer, leftName, rightName string, rows []alert.DiffRow) error {
+	cols := diffColumns(rows, leftName, rightName)
+	cols = append(cols, column[alert.DiffRow]{
+		title: "URL",
+		value: func(r alert.DiffRow) string { return r.Alert().URL },
+	})
+
+	return renderMarkdownTable(w, cols, rows, alert.DiffRow.Alert)
+}
+
+func diffColumns(rows []alert.DiffRow, leftName, rightName string) []column[alert.DiffRow] {
+	cols := []column[alert.DiffRow]{{
+		width: colWidthMarker,
+		value: func(r alert.DiffRow) string {
+			if r.Kind == alert.DiffMatched && !diffRowChanged(r) {
+				return ""
+			}
+			return diffMarkers[r.Kind]
+		},
+	}}
+
+	if multipleAlertNames(rows, func(r alert.DiffRow) map[string]string { return r.Alert().Labels }) {
+		cols = append(cols, column[alert.DiffRow]{
+			title: "Alert",
+			width: colWidthAlert,
+			value: func(r alert.DiffRow) string { return r.Alert().Labels["alertname"] },
+		})
+	}
+
+	cols = append(cols, diffSideColumns(leftName, func(r alert.DiffRow) *alert.Alert { return r.Left })...)
+	cols = append(cols, diffSideColumns(rightName, func(r alert.DiffRow) *alert.Alert { return r.Right })...)
+
+	return append(cols,
+		column[alert.DiffRow]{
+			title: "Opened Δ",
+			width: colWidthDuration,
+			value: func(r alert.DiffRow) string {
+				if r.Kind != alert.DiffMatched {
+					return ""
+				}
+				return formatDurationChange(r.OpenedDelta())
+			},
+		},
+		column[alert.DiffRow]{
+			title: "Resolved Δ",
+			width: colWidthDuration,
+			value: func(r alert.DiffRow) string {
+				if r.Kind != alert.DiffMatched {
+					return ""
+				}
+				delta, ok := r.ResolvedDelta()
+				if !ok {
+					return "--"
+				}
+				return formatDurationChange(delta)
+			},
+		},
+		column[alert.DiffRow]{
+			title: "Labels",
+			value: func(r alert.DiffRow) string { return alert.FormatLabels(r.Alert().Labels) },
+		},
+	)
+}
+
+// diffSideColumns show when the alert on one side of a diff row opened and
+// resolved.
+func diffSideColumns(name string, side func(alert.DiffRow) *alert.Alert) []column[alert.DiffRow] {
+	return []column[alert.DiffRow]{
+		{
+			title: name + " Opened",
+			width: colWidthOpened,
+			value: func(r alert.DiffRow) string {
+				if ar := side(r); ar != nil {
+					return ar.OpenedAt.UTC().Format(outputTimeFormat)
+				}
+				return ""
+			},
+		},
+		{
+			title: name + " Resolved",
+			width: colWidthResolved,
+			value: func(r alert.DiffRow) string {
+				ar := side(r)
+				switch {
+				case ar == nil:
+					return ""
+				case ar.ResolvedAt == nil:
+					return "UNRESOLVED"
+				default:
+					return ar.ResolvedAt.UTC().Format(outputTimeFormat)
+				}
+			},
+		},
+	}
+}
+
+// RenderLabelSetChanges writes the totals of both sides of a diff, followed by
+// the label sets that fired differently, as a markdown table to w.
+func RenderLabelSetChanges(w io.Writer, leftName, rightName string, changes []alert.LabelSetChange) error {
+	t := newMarkdownTable(w,
+		"Labels",
+		leftName+" Alerts",
+		rightName+" Alerts",
+		leftName+" Firing",
+		rightName+" Firing",
+		"Firing Change",
+	)
+
+	var total alert.LabelSetChange
+	for _, c := range changes {
+		total.LeftCount += c.LeftCount
+		total.RightCount += c.RightCount
+		total.LeftFiring += c.LeftFiring
+		total.RightFiring += c.RightFiring
+	}
+
+	t.Row(labelSetChangeRow("Total", total)...)
+	for _, c := range changes {
+		if c.Changed() {
+			t.Row(labelSetChangeRow(alert.FormatLabels(c.Labels), c)...)
+		}
+	}
+
+	_, err := fmt.Fprintln(w, t.Render())
+
+	return err
+}
+
+func labelSetChangeRow(name string, c alert.LabelSetChange) []string {
+	return []string{
+		name,
+		strconv.Itoa(c.LeftCount),
+		strconv.Itoa(c.RightCount),
+		c.LeftFiring.Round(time.Second).String(),
+		c.RightFiring.Round(time.Second).String(),
+		formatDurationChange(c.FiringChange()),
+	}
+}
+
+func diffSummaryHeaders(name1, name2 string) []string {
+	return []string{"Alert", "Status", name1, name2, "Matched", "Only " + name1, "Only " + name2, "Firing Change"}
+}
+
+func diffSummaryRow(name string, s DiffSummary) []string {
+	return []string{
+		name,
+		string(s.Status),
+		strconv.Itoa(s.Count1),
+		strconv.Itoa(s.Count2),
+		strconv.Itoa(s.Matched),
+		strconv.Itoa(s.Only1),
+		strconv.Itoa(s.Only2),
+		formatDurationChange(s.FiringChange),
+	}
+}
+
+func formatDurationChange(d time.Duration) string {
+	d = d.Round(time.Second)
+	if d > 0 {
+		return "+" + d.String()
+	}
+
+	return d.String()
+}
+
+// markdownAnchor returns the anchor GitHub generates for a heading.
+func markdownAnchor(heading string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(heading) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == '-', r == '_':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteRune('-')
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,177 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/steved/alertreplay/internal/alert"
+)
+
+func TestRenderDiffSummaries(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	err := RenderDiffSummaries(&buf, "old.yaml", "new.yaml", []DiffSummary{
+		{
+			AlertName:    "HighErrorRate",
+			Status:       DiffChanged,
+			Count1:       1,
+			Only1:        1,
+			FiringChange: -90 * time.Minute,
+			Rows: []alert.DiffRow{
+				{Kind: alert.DiffOnlyLeft, Left: &alert.Alert{OpenedAt: base, Labels: map[string]string{"job": "api"}}},
+			},
+		},
+		{AlertName: "TargetDown", Status: DiffAdded},
+	})
+	require.NoError(t, err)
+
+	lines := strings.Split(buf.String(), "\n")
+	assert.Equal(t,
+		[]string{"Alert", "Status", "old.yaml", "new.yaml", "Matched", "Only old.yaml", "Only new.yaml", "Firing Change"},
+		splitMarkdownRow(lines[0]),
+	)
+	assert.Equal(t,
+		[]string{"[HighErrorRate](#higherrorrate)", "changed", "1", "0", "0", "1", "0", "-1h30m0s"},
+		splitMarkdownRow(lines[2]),
+	)
+	assert.Contains(t, buf.String(), "\n### HighErrorRate\n\n|   | old.yaml Opened")
+	assert.Contains(t, buf.String(), "\n### TargetDown\n\nNo alert events found.\n")
+}
+
+func TestRenderDiff(t *testing.T) {
+	var (
+		base = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		api  = map[string]string{"alertname": "TargetDown", "job": "api"}
+		db   = map[string]string{"alertname": "TargetDown", "job": "db"}
+		web  = map[string]string{"alertname": "TargetDown", "job": "web"}
+	)
+
+	var buf bytes.Buffer
+	err := RenderDiff(&buf, "old.yaml", "new.yaml", []alert.DiffRow{
+		{
+			Kind:  alert.DiffMatched,
+			Left:  &alert.Alert{OpenedAt: base, ResolvedAt: new(base.Add(time.Hour)), Labels: api},
+			Right: &alert.Alert{OpenedAt: base.Add(time.Minute), ResolvedAt: new(base.Add(30 * time.Minute)), Labels: api},
+		},
+		{
+			Kind:  alert.DiffMatched,
+			Left:  &alert.Alert{OpenedAt: base, ResolvedAt: new(base.Add(time.Hour)), Labels: web},
+			Right: &alert.Alert{OpenedAt: base, Labels: web},
+		},
+		{
+			Kind:  alert.DiffMatched,
+			Left:  &alert.Alert{OpenedAt: base, Labels: web},
+			Right: &alert.Alert{OpenedAt: base, Labels: web},
+		},
+		{Kind: alert.DiffOnlyLeft, Left: &alert.Alert{OpenedAt: base, Labels: db, URL: "http://old"}},
+		{Kind: alert.DiffOnlyRight, Right: &alert.Alert{OpenedAt: base, ResolvedAt: new(base.Add(time.Hour)), Labels: db}},
+	})
+	require.NoError(t, err)
+
+	lines := strings.Split(buf.String(), "\n")
+	assert.Equal(t,
+		[]string{"", "old.yaml Opened", "old.yaml Resolved", "new.yaml Opened", "new.yaml Resolved", "Opened Δ", "Resolved Δ", "Labels", "URL"},
+		splitMarkdownRow(lines[0]),
+	)
+	assert.Equal(t,
+		[]string{"~", "2026-01-01 12:00 UTC", "2026-01-01 13:00 UTC", "2026-01-01 12:01 UTC", "2026-01-01 12:30 UTC", "+1m0s", "-30m0s", `{job="api"}`, ""},
+		splitMarkdownRow(lines[2]),
+	)
+	assert.Equal(t,
+		[]string{"~", "2026-01-01 12:00 UTC", "2026-01-01 13:00 UTC", "2026-01-01 12:00 UTC", "UNRESOLVED", "0s", "--", `{job="web"}`, ""},
+		splitMarkdownRow(lines[3]),
+	)
+	assert.Equal(t,
+		[]string{"", "2026-01-01 12:00 UTC", "UNRESOLVED", "2026-01-01 12:00 UTC", "UNRESOLVED", "0s", "0s", `{job="web"}`, ""},
+		splitMarkdownRow(lines[4]),
+		"matched rows that opened and resolved at the same time aren't marked",
+	)
+	assert.Equal(t,
+		[]string{"-", "2026-01-01 12:00 UTC", "UNRESOLVED", "", "", "", "", `{job="db"}`, "http://old"},
+		splitMarkdownRow(lines[5]),
+	)
+	assert.Equal(t,
+		[]string{"+", "", "", "2026-01-01 12:00 UTC", "2026-01-01 13:00 UTC", "", "", `{job="db"}`, ""},
+		splitMarkdownRow(lines[6]),
+	)
+}
+
+func TestNewDiffModel(t *testing.T) {
+	profile := lipgloss.ColorProfile()
+	lipgloss.SetColorProfile(termenv.ANSI)
+	t.Cleanup(func() { lipgloss.SetColorProfile(profile) })
+
+	var (
+		base = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		rows = []alert.DiffRow{
+			{
+				Kind:  alert.DiffMatched,
+				Left:  &alert.Alert{OpenedAt: base, Labels: map[string]string{"job": "shifted"}},
+				Right: &alert.Alert{OpenedAt: base.Add(time.Minute), Labels: map[string]string{"job": "shifted"}},
+			},
+			{
+				Kind:  alert.DiffMatched,
+				Left:  &alert.Alert{OpenedAt: base, Labels: map[string]string{"job": "same"}},
+				Right: &alert.Alert{OpenedAt: base, Labels: map[string]string{"job": "same"}},
+			},
+			{Kind: alert.DiffOnlyLeft, Left: &alert.Alert{OpenedAt: base, Labels: map[string]string{"job": "removed"}}},
+			{Kind: alert.DiffOnlyRight, Right: &alert.Alert{OpenedAt: base, Labels: map[string]string{"job": "added"}}},
+			{
+				Kind:  alert.DiffOnlyRight,
+				Right: &alert.Alert{OpenedAt: base, Labels: map[string]string{"job": "inhibited"}, Inhibited: &alert.Suppression{Share: 1}},
+			},
+		}
+	)
+
+	// The first row is selected, which is styled inside the colour of the row.
+	m := newDiffModel("old.yaml", "new.yaml", rows, 300, 40)
+
+	lines := make(map[string]string)
+	for line := range strings.Lines(styleRows(m.table.View())) {
+		for _, job := range []string{"same", "shifted", "removed", "added", "inhibited"} {
+			if strings.Contains(line, `job="`+job+`"`) {
+				lines[job] = line
+			}
+		}
+	}
+
+	assert.False(t, strings.HasPrefix(lines["same"], "\x1b["), "unchanged rows aren't styled: %q", lines["same"])
+	assert.True(t, strings.HasPrefix(lines["shifted"], "\x1b[33m"), "changed rows are yellow: %q", lines["shifted"])
+	assert.True(t, strings.HasPrefix(lines["removed"], "\x1b[31m"), "removed rows are red: %q", lines["removed"])
+	assert.True(t, strings.HasPrefix(lines["added"], "\x1b[32m"), "added rows are green: %q", lines["added"])
+	assert.True(t, strings.HasPrefix(lines["inhibited"], "\x1b[2;32m"), "inhibited rows are dimmed: %q", lines["inhibited"])
+	assert.NotContains(t, m.help, "🟢")
+}
+
+func TestRenderLabelSetChanges(t *testing.T) {
+	var buf bytes.Buffer
+	err := RenderLabelSetChanges(&buf, "last week", "this week", []alert.LabelSetChange{
+		{Labels: map[string]string{"job": "db"}, RightCount: 1, RightFiring: 24 * time.Hour},
+		{Labels: map[string]string{"job": "api"}, LeftCount: 2, LeftFiring: 2 * time.Hour},
+		{Labels: map[string]string{"job": "web"}, LeftCount: 1, RightCount: 1, LeftFiring: time.Hour, RightFiring: time.Hour},
+	})
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 5)
+	assert.Equal(t,
+		[]string{"Labels", "last week Alerts", "this week Alerts", "last week Firing", "this week Firing", "Firing Change"},
+		splitMarkdownRow(lines[0]),
+	)
+	assert.Equal(t, []string{"Total", "3", "2", "3h0m0s", "25h0m0s", "+22h0m0s"}, splitMarkdownRow(lines[2]))
+	assert.Equal(t, []string{`{job="db"}`, "0", "1", "0s", "24h0m0s", "+24h0m0s"}, splitMarkdownRow(lines[3]))
+	assert.Equal(t, []string{`{job="api"}`, "2", "0", "2h0m0s", "0s", "-2h0m0s"}, splitMarkdownRow(lines[4]))
+}
+
+func TestMarkdownAnchor(t *testing.T) {
+	assert.Equal(t, "higherrorrate", markdownAnchor("HighErrorRate"))
+	assert.Equal(t, "kube_pod-crashlooping", markdownAnchor("Kube_Pod Crash.Looping"))
+}
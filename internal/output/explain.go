@@ -0,0 +1,155 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/steved/alertreplay/internal/alert"
+	"github.com/steved/alertreplay/internal/explain"
+)
+
+// PrintExplanation writes the values of an alert expression and its
+// sub-expressions to stdout as markdown.
+func PrintExplanation(e explain.Explanation) error {
+	return RenderExplanation(os.Stdout, e)
+}
+
+// RenderExplanation writes the sub-expressions of an alert expression with
+// their series and values, the outcome of each comparison, and the outcome of
+// each comparison at every evaluation of the for window with the series
+// returned over it as markdown tables to w.
+func RenderExplanation(w io.Writer, e explain.Explanation) error {
+	if _, err := fmt.Fprintf(w, "## %s at %s UTC\n\n", e.Alert, e.At.UTC().Format(time.DateTime)); err != nil {
+		return err
+	}
+
+	values := newMarkdownTable(w, "Expression", "Series", "Value")
+	for _, n := range e.Nodes {
+		expr := strings.Repeat("  ", n.Depth) + "`" + n.Expr + "`"
+		if len(n.Samples) == 0 {
+			values.Row(expr, "", "no data")
+			continue
+		}
+
+		for _, s := range n.Samples {
+			values.Row(expr, alert.FormatLabels(s.Metric.Map()), formatValue(s.F))
+		}
+	}
+
+	if _, err := fmt.Fprintln(w, values.Render()); err != nil {
+		return err
+	}
+
+	comparisons := newMarkdownTable(w, "Comparison", "Series", "Left", "Right", "Result", "Margin")
+	for _, n := range e.Nodes {
+		for _, c := range n.Comparisons {
+			right, margin := formatValue(c.Right), formatValueChange(c.Margin())
+			if !c.Matched {
+				right, margin = "no match", ""
+			}
+
+			result := "failed"
+			if c.Passed {
+				result = "passed"
+			}
+
+			comparisons.Row("`"+n.Expr+"`", alert.FormatLabels(c.Labels.Map()), formatValue(c.Left), right, result, margin)
+		}
+	}
+
+	if _, err := fmt.Fprintf(w, "\n### Comparisons\n\n%s\n", comparisons.Render()); err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(w, "\n### For window (%s, %d evaluations)\n\n", e.For, e.Evaluations); err != nil {
+		return err
+	}
+
+	if slices.ContainsFunc(e.Nodes, func(n explain.Node) bool { return len(n.Steps) > 0 }) {
+		steps := newMarkdownTable(w, "Comparison", "Series", "Passed", "Steps")
+		for _, n := range e.Nodes {
+			for _, o := range stepOutcomes(n.Steps) {
+				steps.Row("`"+n.Expr+"`", alert.FormatLabels(o.labels.Map()), fmt.Sprintf("%d/%d", o.passed, len(n.Steps)), string(o.marks))
+			}
+		}
+
+		if _, err := fmt.Fprintf(w, "%s\n\nSteps: `+` passed, `-` failed, `.` no series.\n\n", steps.Render()); err != nil {
+			return err
+		}
+	}
+
+	if len(e.Window) == 0 {
+		_, err := fmt.Fprintln(w, "No series returned in the for window.")
+		return err
+	}
+
+	window := newMarkdownTable(w, "Series", "Active", "Fires")
+	for _, s := range e.Window {
+		fires := "no"
+		if s.Active == e.Evaluations {
+			fires = "yes"
+		}
+
+		window.Row(alert.FormatLabels(s.Labels.Map()), strconv.Itoa(s.Active), fires)
+	}
+
+	_, err := fmt.Fprintln(w, window.Render())
+
+	return err
+}
+
+// stepOutcome is the outcome of a comparison for one series at every step of
+// the for window.
+type stepOutcome struct {
+	labels labels.Labels
+	passed int
+	// marks has a character per step: + when the series passed, - when it
+	// failed and . when it wasn't compared.
+	marks []byte
+}
+
+func stepOutcomes(steps []explain.Step) []stepOutcome {
+	byLabels := make(map[string]*stepOutcome)
+
+	for i, step := range steps {
+		for _, c := range step.Comparisons {
+			key := c.Labels.String()
+			o, ok := byLabels[key]
+			if !ok {
+				o = &stepOutcome{labels: c.Labels, marks: bytes.Repeat([]byte{'.'}, len(steps))}
+				byLabels[key] = o
+			}
+
+			o.marks[i] = '-'
+			if c.Passed {
+				o.marks[i] = '+'
+				o.passed++
+			}
+		}
+	}
+
+	outcomes := make([]stepOutcome, 0, len(byLabels))
+	for _, o := range byLabels {
+		outcomes = append(outcomes, *o)
+	}
+
+	slices.SortFunc(outcomes, func(a, b stepOutcome) int { return labels.Compare(a.labels, b.labels) })
+
+	return outcomes
+}
+
+func formatValueChange(v float64) string {
+	if v > 0 {
+		return "+" + formatValue(v)
+	}
+
+	return formatValue(v)
+}
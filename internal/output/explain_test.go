@@ -0,0 +1,72 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/steved/alertreplay/internal/explain"
+)
+
+func TestRenderExplanation(t *testing.T) {
+	var (
+		api = labels.FromStrings("job", "api")
+		db  = labels.FromStrings("job", "db")
+	)
+
+	var buf bytes.Buffer
+	err := RenderExplanation(&buf, explain.Explanation{
+		Alert: "HighErrorRate",
+		At:    time.Date(2026, 1, 3, 4, 5, 0, 0, time.UTC),
+		For:   2 * time.Minute,
+		Nodes: []explain.Node{
+			{
+				Expr:    "ratio > 0.05",
+				Samples: promql.Vector{{F: 0.1, Metric: api}},
+				Comparisons: []explain.Comparison{
+					{Op: ">", Labels: api, Left: 0.1, Right: 0.05, Matched: true, Passed: true},
+					{Op: ">", Labels: db, Left: 0.01, Right: 0.05, Matched: true},
+				},
+				Steps: []explain.Step{
+					{Comparisons: []explain.Comparison{
+						{Op: ">", Labels: api, Left: 0.1, Right: 0.05, Matched: true, Passed: true},
+					}},
+					{Comparisons: []explain.Comparison{
+						{Op: ">", Labels: api, Left: 0.01, Right: 0.05, Matched: true},
+						{Op: ">", Labels: db, Left: 0.01, Right: 0.05, Matched: true},
+					}},
+					{Comparisons: []explain.Comparison{
+						{Op: ">", Labels: api, Left: 0.1, Right: 0.05, Matched: true, Passed: true},
+						{Op: ">", Labels: db, Left: 0.01, Right: 0.05, Matched: true},
+					}},
+				},
+			},
+			{Expr: "ratio", Depth: 1, Samples: promql.Vector{{F: 0.1, Metric: api}, {F: 0.01, Metric: db}}},
+			{Expr: "0.05", Depth: 1},
+		},
+		Evaluations: 3,
+		Window: []explain.Series{
+			{Labels: api, Active: 3},
+			{Labels: db, Active: 1},
+		},
+	})
+	require.NoError(t, err)
+
+	out := buf.String()
+	assert.Contains(t, out, "## HighErrorRate at 2026-01-03 04:05:00 UTC")
+	assert.Regexp(t, "`ratio > 0.05` +\\| \\{job=\"api\"\\} +\\| 0.1 ", out)
+	assert.Regexp(t, "  `ratio` +\\| \\{job=\"db\"\\} +\\| 0.01 ", out)
+	assert.Regexp(t, "  `0.05` +\\| +\\| no data", out)
+	assert.Regexp(t, "\\{job=\"api\"\\} +\\| 0.1 +\\| 0.05 +\\| passed +\\| \\+0.05 ", out)
+	assert.Regexp(t, "\\{job=\"db\"\\} +\\| 0.01 +\\| 0.05 +\\| failed +\\| -0.04 ", out)
+	assert.Contains(t, out, "### For window (2m0s, 3 evaluations)")
+	assert.Regexp(t, "`ratio > 0.05` +\\| \\{job=\"api\"\\} +\\| 2/3 +\\| \\+-\\+ ", out)
+	assert.Regexp(t, "`ratio > 0.05` +\\| \\{job=\"db\"\\} +\\| 0/3 +\\| \\.-- ", out)
+	assert.Regexp(t, "\\{job=\"api\"\\} +\\| 3 +\\| yes", out)
+	assert.Regexp(t, "\\{job=\"db\"\\} +\\| 1 +\\| no", out)
+}
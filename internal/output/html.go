@@ -0,0 +1,552 @@
+package output
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/steved/alertreplay/internal/alert"
+)
+
+const (
+	svgWidth        = 1000
+	svgLabelWidth   = 320
+	svgPlotWidth    = svgWidth - svgLabelWidth - 10
+	svgRowHeight    = 18
+	svgAxisHeight   = 24
+	svgChartHeight  = 260
+	svgLabelChars   = 48
+	chartMaxSeries  = 10
+	chartGapFactor  = 1.5
+	chartMaxSamples = 2 * svgPlotWidth
+)
+
+// Sides of a report, which select the colour of bars and lines.
+const (
+	sideReplay = "replay"
+	sideLeft   = "left"
+	sideRight  = "right"
+)
+
+//go:embed html.tmpl
+var htmlTemplateText string
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"px": func(v float64) string { return strconv.FormatFloat(v, 'f', 1, 64) },
+}).Parse(htmlTemplateText))
+
+func init() {
+	Register(FormatHTML, htmlWriter{})
+}
+
+// htmlWriter writes replays and diffs as a single HTML file that needs no
+// network access to view: styles are inline and charts are SVG.
+type htmlWriter struct{}
+
+func (htmlWriter) WriteReplay(w io.Writer, r ReplayResult) error {
+	scale := newTimeScale(r.Run.From, r.Run.To)
+
+	section := htmlSection{
+		Timeline: newTimeline(scale, timelineBars(r.Alerts, sideReplay, 0)),
+		Chart:    newChart(scale, r.Run.Interval, chartSeriesOf(r.Series, sideReplay, 0)),
+		Alerts:   htmlAlerts(r.Alerts, sideReplay),
+	}
+
+	return renderHTML(w, htmlReport{
+		Title:    "alertreplay " + r.Run.Command,
+		Run:      newRunJSON(r.Run),
+		Sections: []htmlSection{section},
+	})
+}
+
+func (htmlWriter) WriteDiff(w io.Writer, d DiffResult) error {
+	report := htmlReport{
+		Title:     "alertreplay " + d.Run.Command,
+		Run:       newRunJSON(d.Run),
+		LeftName:  d.LeftName,
+		RightName: d.RightName,
+	}
+
+	// Both sides are drawn on the period replayed on the right, with the
+	// left side moved forward by the difference between their ends.
+	rightTo := d.RightTo
+	if rightTo.IsZero() {
+		rightTo = d.Run.To
+	}
+	offset := time.Duration(0)
+	if !d.LeftTo.IsZero() {
+		offset = rightTo.Sub(d.LeftTo)
+	}
+	scale := newTimeScale(rightTo.Add(-d.Run.To.Sub(d.Run.From)), rightTo)
+
+	diffSection := func(title string, rows []alert.DiffRow, leftSeries, rightSeries []alert.Series) htmlSection {
+		var left, right []alert.Alert
+		for _, row := range rows {
+			if row.Left != nil {
+				left = append(left, *row.Left)
+			}
+			if row.Right != nil {
+				right = append(right, *row.Right)
+			}
+		}
+
+		series := chartSeriesOf(leftSeries, sideLeft, offset)
+		series = append(series, chartSeriesOf(rightSeries, sideRight, 0)...)
+
+		return htmlSection{
+			Title:    title,
+			Anchor:   markdownAnchor(title),
+			Timeline: newTimeline(scale, append(timelineBars(left, sideLeft, offset), timelineBars(right, sideRight, 0)...)),
+			Chart:    newChart(scale, d.Run.Interval, series),
+			Alerts:   append(htmlAlerts(left, sideLeft), htmlAlerts(right, sideRight)...),
+		}
+	}
+
+	if d.Summaries == nil {
+		report.Sections = []htmlSection{diffSection("", d.Rows, d.LeftSeries, d.RightSeries)}
+	} else {
+		report.SummaryHeaders = diffSummaryHeaders(d.LeftName, d.RightName)
+		for _, s := range d.Summaries {
+			report.Summaries = append(report.Summaries, htmlSummary{
+				Anchor: markdownAnchor(s.AlertName),
+				Cells:  diffSummaryRow(s.AlertName, s),
+			})
+			report.Sections = append(report.Sections, diffSection(s.AlertName, s.Rows, s.LeftSeries, s.RightSeries))
+		}
+	}
+
+	return renderHTML(w, report)
+}
+
+func renderHTML(w io.Writer, report htmlReport) error {
+	if err := htmlTemplate.Execute(w, report); err != nil {
+		return fmt.Errorf("rendering html: %w", err)
+	}
+
+	return nil
+}
+
+// htmlReport is the data of the HTML template.
+type htmlReport struct {
+	Title string
+	Run   runJSON
+	// LeftName and RightName are set for diffs.
+	LeftName       string
+	RightName      string
+	SummaryHeaders []string
+	Summaries      []htmlSummary
+	Sections       []htmlSection
+}
+
+type htmlSummary struct {
+	Anchor string
+	Cells  []string
+}
+
+// htmlSection shows the alerts of a single alert rule.
+type htmlSection struct {
+	Title    string
+	Anchor   string
+	Timeline svgTimeline
+	Chart    svgChart
+	Alerts   []htmlAlert
+}
+
+type htmlAlert struct {
+	Side     string
+	Source   string
+	Opened   string
+	Resolved string
+	Duration string
+	Labels   string
+	URL      string
+}
+
+func htmlAlerts(alerts []alert.Alert, side string) []htmlAlert {
+	rows := make([]htmlAlert, 0, len(alerts))
+	for _, ar := range alerts {
+		row := htmlAlert{
+			Side:     side,
+			Source:   ar.Source,
+			Opened:   ar.OpenedAt.UTC().Format(outputTimeFormat),
+			Resolved: "UNRESOLVED",
+			Duration: "--",
+			Labels:   alert.FormatGroupLabels(ar.Labels),
+			URL:      ar.URL,
+		}
+		if ar.ResolvedAt != nil {
+			row.Resolved = ar.ResolvedAt.UTC().Format(outputTimeFormat)
+			row.Duration = ar.ResolvedAt.Sub(ar.OpenedAt).Round(time.Second).String()
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+// timeScale maps times between from and to onto the x axis of the plots.
+type timeScale struct {
+	from, to time.Time
+}
+
+func newTimeScale(from, to time.Time) timeScale {
+	if !to.After(from) {
+		to = from.Add(time.Minute)
+	}
+
+	return timeScale{from: from, to: to}
+}
+
+// x returns the position of t, clamped to the plot.
+func (s timeScale) x(t time.Time) float64 {
+	frac := float64(t.Sub(s.from)) / float64(s.to.Sub(s.from))
+
+	return svgLabelWidth + math.Min(math.Max(frac, 0), 1)*svgPlotWidth
+}
+
+// tickSteps are the candidate distances between ticks of the time axis.
+var tickSteps = []time.Duration{
+	time.Minute, 5 * time.Minute, 10 * time.Minute, 15 * time.Minute, 30 * time.Minute,
+	time.Hour, 2 * time.Hour, 3 * time.Hour, 6 * time.Hour, 12 * time.Hour,
+	24 * time.Hour, 2 * 24 * time.Hour, 7 * 24 * time.Hour,
+}
+
+// ticks returns at most about eight evenly spaced ticks on the time axis.
+func (s timeScale) ticks() []svgTick {
+	span := s.to.Sub(s.from)
+
+	step := tickSteps[len(tickSteps)-1]
+	for _, candidate := range tickSteps {
+		if span/candidate <= 8 {
+			step = candidate
+			break
+		}
+	}
+
+	layout := "15:04"
+	if span > 24*time.Hour {
+		layout = "01-02 15:04"
+	}
+
+	var ticks []svgTick
+	for t := s.from.UTC().Truncate(step); !t.After(s.to); t = t.Add(step) {
+		if t.Before(s.from) {
+			continue
+		}
+		ticks = append(ticks, svgTick{Pos: s.x(t), Label: t.Format(layout)})
+	}
+
+	return ticks
+}
+
+type svgTick struct {
+	Pos   float64
+	Label string
+}
+
+// svgTimeline is a Gantt chart of alerts with a row per label set.
+type svgTimeline struct {
+	Height float64
+	AxisY  float64
+	Rows   []svgRow
+	Bars   []svgBar
+	Ticks  []svgTick
+}
+
+type svgRow struct {
+	Y     float64
+	Label string
+	Title string
+}
+
+type svgBar struct {
+	X, Y, W, H float64
+	Side       string
+	Title      string
+	URL        string
+}
+
+// timelineBar is an alert placed on the timeline. Times are already moved by
+// the offset of its side, and until is zero while the alert is unresolved.
+type timelineBar struct {
+	labels      string
+	from, until time.Time
+	side        string
+	title       string
+	url         string
+}
+
+func timelineBars(alerts []alert.Alert, side string, offset time.Duration) []timelineBar {
+	multipleNames := multipleAlertNames(alerts, func(ar alert.Alert) map[string]string { return ar.Labels })
+
+	bars := make([]timelineBar, 0, len(alerts))
+	for _, ar := range alerts {
+		labels := alert.FormatLabels(ar.Labels)
+		if multipleNames {
+			labels = alert.FormatGroupLabels(ar.Labels)
+		}
+
+		var (
+			until    time.Time
+			resolved = "UNRESOLVED"
+		)
+		if ar.ResolvedAt != nil {
+			until = ar.ResolvedAt.Add(offset)
+			resolved = ar.ResolvedAt.UTC().Format(outputTimeFormat)
+		}
+
+		bars = append(bars, timelineBar{
+			labels: labels,
+			from:   ar.OpenedAt.Add(offset),
+			until:  until,
+			side:   side,
+			title:  fmt.Sprintf("%s %s → %s", labels, ar.OpenedAt.UTC().Format(outputTimeFormat), resolved),
+			url:    ar.URL,
+		})
+	}
+
+	return bars
+}
+
+func newTimeline(scale timeScale, bars []timelineBar) svgTimeline {
+	var labelSets []string
+	for _, b := range bars {
+		if !slices.Contains(labelSets, b.labels) {
+			labelSets = append(labelSets, b.labels)
+		}
+	}
+	slices.Sort(labelSets)
+
+	tl := svgTimeline{
+		AxisY:  float64(len(labelSets) * svgRowHeight),
+		Height: float64(len(labelSets)*svgRowHeight + svgAxisHeight),
+		Ticks:  scale.ticks(),
+	}
+
+	for i, labels := range labelSets {
+		tl.Rows = append(tl.Rows, svgRow{
+			Y:     float64(i*svgRowHeight) + svgRowHeight*0.7,
+			Label: truncateLabel(labels),
+			Title: labels,
+		})
+	}
+
+	for _, b := range bars {
+		row := slices.Index(labelSets, b.labels)
+
+		// Diffs split each row so both sides stay visible where they overlap.
+		y, h := float64(row*svgRowHeight)+2, float64(svgRowHeight-4)
+		switch b.side {
+		case sideLeft:
+			h /= 2
+		case sideRight:
+			h /= 2
+			y += h
+		}
+
+		until := b.until
+		if until.IsZero() {
+			until = scale.to
+		}
+
+		x := scale.x(b.from)
+		tl.Bars = append(tl.Bars, svgBar{
+			X:     x,
+			Y:     y,
+			W:     math.Max(scale.x(until)-x, 1),
+			H:     h,
+			Side:  b.side,
+			Title: b.title,
+			URL:   b.url,
+		})
+	}
+
+	return tl
+}
+
+func truncateLabel(s string) string {
+	if r := []rune(s); len(r) > svgLabelChars {
+		return string(r[:svgLabelChars-1]) + "…"
+	}
+
+	return s
+}
+
+// svgChart plots the values of the alert expression over time.
+type svgChart struct {
+	Lines  []svgLine
+	Ticks  []svgTick
+	YTicks []svgTick
+	// Shown and Total count the series plotted and returned.
+	Shown int
+	Total int
+}
+
+type svgLine struct {
+	Side   string
+	Color  int
+	Points []string
+	Title  string
+}
+
+// chartSeries is a series of a side with its times already moved by the
+// offset of the side.
+type chartSeries struct {
+	side   string
+	labels string
+	points []alert.Point
+	peak   float64
+}
+
+func chartSeriesOf(series []alert.Series, side string, offset time.Duration) []chartSeries {
+	out := make([]chartSeries, 0, len(series))
+	for _, s := range series {
+		cs := chartSeries{side: side, labels: alert.FormatGroupLabels(s.Labels), peak: math.Inf(-1)}
+		for _, p := range s.Points {
+			if math.IsNaN(p.V) || math.IsInf(p.V, 0) {
+				continue
+			}
+			cs.points = append(cs.points, alert.Point{T: p.T.Add(offset), V: p.V})
+			cs.peak = math.Max(cs.peak, p.V)
+		}
+
+		if len(cs.points) > 0 {
+			out = append(out, cs)
+		}
+	}
+
+	return out
+}
+
+// newChart plots the series with the highest peaks, breaking lines where
+// evaluations returned nothing for longer than the interval.
+func newChart(scale timeScale, interval time.Duration, series []chartSeries) svgChart {
+	chart := svgChart{Total: len(series), Ticks: scale.ticks()}
+	if len(series) == 0 {
+		return chart
+	}
+
+	slices.SortStableFunc(series, func(a, b chartSeries) int { return -cmpFloat(a.peak, b.peak) })
+	series = series[:min(len(series), chartMaxSeries)]
+	chart.Shown = len(series)
+
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, s := range series {
+		for _, p := range s.points {
+			lo, hi = math.Min(lo, p.V), math.Max(hi, p.V)
+		}
+	}
+	if lo == hi {
+		lo, hi = lo-1, hi+1
+	}
+
+	y := func(v float64) float64 { return (hi - v) / (hi - lo) * svgChartHeight }
+
+	for i := range 5 {
+		v := hi - (hi-lo)*float64(i)/4
+		chart.YTicks = append(chart.YTicks, svgTick{Pos: y(v), Label: formatValue(v)})
+	}
+
+	for i, s := range series {
+		line := svgLine{Side: s.side, Color: i, Title: s.labels}
+
+		for _, segment := range splitGaps(s.points, interval) {
+			var b strings.Builder
+			for j, p := range downsample(segment, scale) {
+				if j > 0 {
+					b.WriteByte(' ')
+				}
+				fmt.Fprintf(&b, "%.1f,%.1f", scale.x(p.T), y(p.V))
+			}
+			line.Points = append(line.Points, b.String())
+		}
+
+		chart.Lines = append(chart.Lines, line)
+	}
+
+	return chart
+}
+
+func cmpFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// splitGaps splits points where more than one and a half intervals pass
+// between them.
+func splitGaps(points []alert.Point, interval time.Duration) [][]alert.Point {
+	if interval <= 0 {
+		return [][]alert.Point{points}
+	}
+
+	var (
+		segments [][]alert.Point
+		start    int
+	)
+	for i := 1; i < len(points); i++ {
+		if float64(points[i].T.Sub(points[i-1].T)) > chartGapFactor*float64(interval) {
+			segments = append(segments, points[start:i])
+			start = i
+		}
+	}
+
+	return append(segments, points[start:])
+}
+
+// downsample keeps the lowest and highest point of each pixel column when
+// there are more points than the plot can show, so spikes stay visible.
+func downsample(points []alert.Point, scale timeScale) []alert.Point {
+	if len(points) <= chartMaxSamples {
+		return points
+	}
+
+	var (
+		out        []alert.Point
+		column     = -1
+		minP, maxP alert.Point
+	)
+
+	flush := func() {
+		if column < 0 {
+			return
+		}
+		if minP.T.After(maxP.T) {
+			minP, maxP = maxP, minP
+		}
+		out = append(out, minP)
+		if maxP != minP {
+			out = append(out, maxP)
+		}
+	}
+
+	for _, p := range points {
+		c := int(scale.x(p.T))
+		if c != column {
+			flush()
+			column, minP, maxP = c, p, p
+			continue
+		}
+		if p.V < minP.V {
+			minP = p
+		}
+		if p.V > maxP.V {
+			maxP = p
+		}
+	}
+	flush()
+
+	return out
+}
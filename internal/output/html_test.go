@@ -0,0 +1,131 @@
+package output
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/steved/alertreplay/internal/alert"
+)
+
+func TestHTMLWriter_WriteReplay(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	err := htmlWriter{}.WriteReplay(&buf, ReplayResult{
+		Run: Run{
+			Command:  "replay",
+			From:     base,
+			To:       base.Add(time.Hour),
+			Interval: time.Minute,
+			Rules:    []RunRule{{Datasource: "http://localhost:9090", Alert: "HighErrorRate", Expr: `rate(errors[5m]) > 1`}},
+		},
+		Alerts: []alert.Alert{
+			{
+				OpenedAt:   base.Add(10 * time.Minute),
+				ResolvedAt: new(base.Add(20 * time.Minute)),
+				Labels:     map[string]string{"alertname": "HighErrorRate", "job": "api"},
+				URL:        "http://localhost:9090/graph?g0.expr=up",
+			},
+			{OpenedAt: base.Add(40 * time.Minute), Labels: map[string]string{"alertname": "HighErrorRate", "job": "db"}},
+		},
+		Series: []alert.Series{{
+			Labels: map[string]string{"job": "api"},
+			Points: []alert.Point{{T: base, V: 0.5}, {T: base.Add(time.Minute), V: math.NaN()}, {T: base.Add(10 * time.Minute), V: 2}},
+		}},
+	})
+	require.NoError(t, err)
+
+	out := buf.String()
+	assert.Contains(t, out, "<svg")
+	assert.Contains(t, out, `rate(errors[5m]) &gt; 1`)
+	assert.Contains(t, out, `<a href="http://localhost:9090/graph?g0.expr=up"`)
+	assert.Contains(t, out, "{job=&#34;api&#34;}")
+	assert.Contains(t, out, "{job=&#34;db&#34;}")
+	assert.Contains(t, out, "UNRESOLVED")
+	assert.Equal(t, 2, strings.Count(out, "<polyline"), "the gap after the NaN splits the line")
+	assert.NotContains(t, out, "<script")
+	assert.NotContains(t, out, "<link")
+}
+
+func TestHTMLWriter_WriteDiff(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	left := []alert.Alert{{OpenedAt: base, ResolvedAt: new(base.Add(time.Minute)), Labels: map[string]string{"alertname": "A", "job": "api"}}}
+	right := []alert.Alert{{OpenedAt: base.Add(30 * time.Minute), Labels: map[string]string{"alertname": "A", "job": "db"}}}
+	rows := alert.Diff(left, right, alert.DefaultMatcher)
+
+	var buf bytes.Buffer
+	err := htmlWriter{}.WriteDiff(&buf, DiffResult{
+		Run:       Run{Command: "diff-all", From: base, To: base.Add(time.Hour), Interval: time.Minute},
+		LeftName:  "old.yaml",
+		RightName: "new.yaml",
+		LeftTo:    base.Add(time.Hour),
+		RightTo:   base.Add(time.Hour),
+		Summaries: []DiffSummary{{
+			AlertName:   "A",
+			Status:      DiffChanged,
+			Rows:        rows,
+			LeftSeries:  []alert.Series{{Points: []alert.Point{{T: base, V: 1}}}},
+			RightSeries: []alert.Series{{Points: []alert.Point{{T: base, V: 2}}}},
+		}},
+	})
+	require.NoError(t, err)
+
+	out := buf.String()
+	assert.Contains(t, out, `<a href="#a">A</a>`)
+	assert.Contains(t, out, `<section id="a">`)
+	assert.Contains(t, out, `<rect class="left"`)
+	assert.Contains(t, out, `<rect class="right"`)
+	assert.Contains(t, out, `<polyline class="left"`)
+	assert.Contains(t, out, `<polyline class="right"`)
+	assert.Contains(t, out, "old.yaml")
+	assert.Contains(t, out, "new.yaml")
+}
+
+func TestNewChart(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	scale := newTimeScale(base, base.Add(time.Hour))
+
+	var series []alert.Series
+	for i := range chartMaxSeries + 2 {
+		series = append(series, alert.Series{Points: []alert.Point{{T: base, V: float64(i)}}})
+	}
+
+	chart := newChart(scale, time.Minute, chartSeriesOf(series, sideReplay, 0))
+	assert.Equal(t, chartMaxSeries, chart.Shown)
+	assert.Equal(t, chartMaxSeries+2, chart.Total)
+	assert.Equal(t, "320.0,0.0", chart.Lines[0].Points[0], "the highest peak comes first")
+}
+
+func TestSplitGaps(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	points := []alert.Point{
+		{T: base},
+		{T: base.Add(time.Minute)},
+		{T: base.Add(5 * time.Minute)},
+	}
+
+	assert.Equal(t, [][]alert.Point{points[:2], points[2:]}, splitGaps(points, time.Minute))
+	assert.Equal(t, [][]alert.Point{points}, splitGaps(points, 0))
+}
+
+func TestDownsample(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	scale := newTimeScale(base, base.Add(time.Hour))
+
+	var points []alert.Point
+	for i := range 10 * chartMaxSamples {
+		points = append(points, alert.Point{T: base.Add(time.Duration(i) * time.Hour / time.Duration(10*chartMaxSamples)), V: 1})
+	}
+	points[100].V = 50
+
+	sampled := downsample(points, scale)
+	assert.LessOrEqual(t, len(sampled), 2*(svgPlotWidth+1))
+	assert.Contains(t, sampled, points[100], "spikes are kept")
+}
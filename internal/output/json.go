@@ -0,0 +1,399 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/steved/alertreplay/internal/alert"
+	"github.com/steved/alertreplay/internal/alertmanager"
+)
+
+// SchemaVersion is the version of the JSON and NDJSON output. It is incremented
+// on changes that break consumers, not when fields are added.
+const SchemaVersion = 1
+
+// Run describes how the alerts of a replay or diff were produced.
+type Run struct {
+	Command  string
+	From     time.Time
+	To       time.Time
+	Interval time.Duration
+	Rules    []RunRule
+}
+
+// RunRule is an alert rule replayed in a run.
+type RunRule struct {
+	// Source names the side of a diff the rule was replayed on.
+	Source     string
+	Datasource string
+	Tenant     string
+	Alert      string
+	Expr       string
+	For        time.Duration
+	From       time.Time
+	To         time.Time
+}
+
+// runJSON is the JSON schema of a Run, with times in RFC3339 and durations
+// as strings such as "5m0s".
+type runJSON struct {
+	Command  string        `json:"command"`
+	From     string        `json:"from"`
+	To       string        `json:"to"`
+	Interval string        `json:"interval"`
+	Rules    []runRuleJSON `json:"rules"`
+}
+
+type runRuleJSON struct {
+	Source     string `json:"source,omitempty"`
+	Datasource string `json:"datasource"`
+	Tenant     string `json:"tenant,omitempty"`
+	Alert      string `json:"alert"`
+	Expr       string `json:"expr"`
+	For        string `json:"for"`
+	From       string `json:"from"`
+	To         string `json:"to"`
+}
+
+func newRunJSON(run Run) runJSON {
+	rules := make([]runRuleJSON, 0, len(run.Rules))
+	for _, r := range run.Rules {
+		rules = append(rules, runRuleJSON{
+			Source:     r.Source,
+			Datasource: r.Datasource,
+			Tenant:     r.Tenant,
+			Alert:      r.Alert,
+			Expr:       r.Expr,
+			For:        r.For.String(),
+			From:       r.From.UTC().Format(time.RFC3339),
+			To:         r.To.UTC().Format(time.RFC3339),
+		})
+	}
+
+	return runJSON{
+		Command:  run.Command,
+		From:     run.From.UTC().Format(time.RFC3339),
+		To:       run.To.UTC().Format(time.RFC3339),
+		Interval: run.Interval.String(),
+		Rules:    rules,
+	}
+}
+
+// Record is an alert in the JSON, NDJSON, CSV and TSV output.
+type Record struct {
+	Alertname string            `json:"alertname"`
+	Labels    map[string]string `json:"labels"`
+	// PendingAt is when the alert condition first became true, if known.
+	PendingAt *string `json:"pendingAt,omitempty"`
+	OpenedAt  string  `json:"openedAt"`
+	// ResolvedAt is null for alerts still firing at the end of the range.
+	ResolvedAt *string `json:"resolvedAt"`
+	// KeepFiringSince is when the expression stopped returning the alert while
+	// keep_firing_for held it firing.
+	KeepFiringSince *string `json:"keepFiringSince,omitempty"`
+	// DurationSeconds is how long the alert fired, until the end of the range
+	// for unresolved alerts.
+	DurationSeconds float64            `json:"durationSeconds"`
+	Source          string             `json:"source,omitempty"`
+	URL             string             `json:"url,omitempty"`
+	Values          *RecordValues      `json:"values,omitempty"`
+	Silenced        *RecordSuppression `json:"silenced,omitempty"`
+	Inhibited       *RecordSuppression `json:"inhibited,omitempty"`
+	Annotations     map[string]string  `json:"annotations,omitempty"`
+	// Diff is the kind of diff row the alert belongs to, and DiffRow its
+	// index, shared by both alerts of a matched row.
+	Diff    alert.DiffKind `json:"diff,omitempty"`
+	DiffRow *int           `json:"diffRow,omitempty"`
+}
+
+// RecordSuppression is when a silence or an inhibit rule muted an alert, and
+// which share of its firing time that covers.
+type RecordSuppression struct {
+	Share     float64          `json:"share"`
+	Intervals []RecordInterval `json:"intervals"`
+}
+
+type RecordInterval struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// RecordValues are the expression values of an alert.
+type RecordValues struct {
+	Firing Float `json:"firing"`
+	Peak   Float `json:"peak"`
+	Min    Float `json:"min"`
+	Last   Float `json:"last"`
+}
+
+// Float is an expression value. JSON has no NaN or infinities, so those are
+// encoded as the strings "NaN", "+Inf" and "-Inf", as Prometheus writes them.
+type Float float64
+
+// MarshalJSON implements json.Marshaler.
+func (f Float) MarshalJSON() ([]byte, error) {
+	v := float64(f)
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return []byte(strconv.Quote(strconv.FormatFloat(v, 'g', -1, 64))), nil
+	}
+
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (f *Float) UnmarshalJSON(data []byte) error {
+	var s string
+	if json.Unmarshal(data, &s) == nil {
+		v, err := strconv.ParseFloat(s, 64)
+		*f = Float(v)
+
+		return err
+	}
+
+	var v float64
+	err := json.Unmarshal(data, &v)
+	*f = Float(v)
+
+	return err
+}
+
+// AlertRecords converts alerts to records, with unresolved alerts firing until
+// to.
+func AlertRecords(alerts []alert.Alert, to time.Time) []Record {
+	records := make([]Record, 0, len(alerts))
+	for _, ar := range alerts {
+		records = append(records, newRecord(ar, ar.Source, to))
+	}
+
+	return records
+}
+
+// DiffRecords converts the rows of a diff to records, one per alert, with the
+// alerts of the left side named leftName and firing until leftTo when
+// unresolved, and those of the right side named rightName and firing until
+// rightTo.
+func DiffRecords(leftName, rightName string, rows []alert.DiffRow, leftTo, rightTo time.Time) []Record {
+	var records []Record
+	for i, row := range rows {
+		if row.Left != nil {
+			r := newRecord(*row.Left, leftName, leftTo)
+			r.Diff, r.DiffRow = row.Kind, new(i)
+			records = append(records, r)
+		}
+
+		if row.Right != nil {
+			r := newRecord(*row.Right, rightName, rightTo)
+			r.Diff, r.DiffRow = row.Kind, new(i)
+			records = append(records, r)
+		}
+	}
+
+	return records
+}
+
+func newRecord(ar alert.Alert, source string, to time.Time) Record {
+	r := Record{
+		Alertname:       ar.Labels["alertname"],
+		Labels:          ar.Labels,
+		OpenedAt:        ar.OpenedAt.UTC().Format(time.RFC3339),
+		DurationSeconds: ar.FiringUntil(to).Sub(ar.OpenedAt).Seconds(),
+		Source:          source,
+		URL:             ar.URL,
+		Annotations:     ar.Annotations,
+	}
+
+	if !ar.PendingAt.IsZero() {
+		r.PendingAt = new(ar.PendingAt.UTC().Format(time.RFC3339))
+	}
+
+	if ar.ResolvedAt != nil {
+		r.ResolvedAt = new(ar.ResolvedAt.UTC().Format(time.RFC3339))
+	}
+
+	if ar.KeepFiringSince != nil {
+		r.KeepFiringSince = new(ar.KeepFiringSince.UTC().Format(time.RFC3339))
+	}
+
+	if ar.Values != nil {
+		r.Values = &RecordValues{Firing: Float(ar.Values.Firing), Peak: Float(ar.Values.Peak), Min: Float(ar.Values.Min), Last: Float(ar.Values.Last)}
+	}
+
+	r.Silenced = newRecordSuppression(ar.Silenced)
+	r.Inhibited = newRecordSuppression(ar.Inhibited)
+
+	return r
+}
+
+func newRecordSuppression(s *alert.Suppression) *RecordSuppression {
+	if s == nil {
+		return nil
+	}
+
+	rs := &RecordSuppression{Share: s.Share, Intervals: make([]RecordInterval, 0, len(s.Intervals))}
+	for _, iv := range s.Intervals {
+		rs.Intervals = append(rs.Intervals, RecordInterval{
+			Start: iv.Start.UTC().Format(time.RFC3339),
+			End:   iv.End.UTC().Format(time.RFC3339),
+		})
+	}
+
+	return rs
+}
+
+// NearMissRecord is a near miss in the JSON and NDJSON output.
+type NearMissRecord struct {
+	Alertname      string            `json:"alertname"`
+	Labels         map[string]string `json:"labels"`
+	PendingAt      string            `json:"pendingAt"`
+	ClearedAt      string            `json:"clearedAt"`
+	PendingSeconds float64           `json:"pendingSeconds"`
+	For            string            `json:"for"`
+	Source         string            `json:"source,omitempty"`
+}
+
+// NotificationRecord is a notification in the JSON and NDJSON output.
+type NotificationRecord struct {
+	SentAt   string              `json:"sentAt"`
+	Receiver string              `json:"receiver"`
+	Reason   alertmanager.Reason `json:"reason"`
+	Firing   int                 `json:"firing"`
+	Resolved int                 `json:"resolved"`
+	Group    map[string]string   `json:"group"`
+}
+
+// Reports are the near misses and notifications of a replay, written after
+// its alerts. Nil reports weren't requested and aren't written.
+type Reports struct {
+	NearMisses    []NearMissRecord
+	Notifications []NotificationRecord
+}
+
+// ReplayReports converts the near misses and notifications requested in r to
+// records.
+func ReplayReports(r ReplayResult) Reports {
+	var reports Reports
+
+	if r.WithNearMisses {
+		reports.NearMisses = make([]NearMissRecord, 0, len(r.NearMisses))
+		for _, nm := range r.NearMisses {
+			reports.NearMisses = append(reports.NearMisses, NearMissRecord{
+				Alertname:      nm.Labels["alertname"],
+				Labels:         nm.Labels,
+				PendingAt:      nm.PendingAt.UTC().Format(time.RFC3339),
+				ClearedAt:      nm.ClearedAt.UTC().Format(time.RFC3339),
+				PendingSeconds: nm.Duration().Seconds(),
+				For:            nm.For.String(),
+				Source:         nm.Source,
+			})
+		}
+	}
+
+	if r.WithNotifications {
+		reports.Notifications = make([]NotificationRecord, 0, len(r.Notifications))
+		for _, n := range r.Notifications {
+			reports.Notifications = append(reports.Notifications, NotificationRecord{
+				SentAt:   n.Time.UTC().Format(time.RFC3339),
+				Receiver: n.Receiver,
+				Reason:   n.Reason,
+				Firing:   n.Firing,
+				Resolved: n.Resolved,
+				Group:    n.Group,
+			})
+		}
+	}
+
+	return reports
+}
+
+func init() {
+	Register(FormatJSON, recordsWriter{render: RenderJSON})
+	Register(FormatNDJSON, recordsWriter{render: RenderNDJSON})
+}
+
+// recordsWriter writes the alerts of replays and diffs as records.
+type recordsWriter struct {
+	render func(w io.Writer, run Run, records []Record, reports Reports) error
+	// alertsOnly writers don't write the reports of replays.
+	alertsOnly bool
+}
+
+func (rw recordsWriter) writesReports() bool {
+	return !rw.alertsOnly
+}
+
+func (rw recordsWriter) WriteReplay(w io.Writer, r ReplayResult) error {
+	return rw.render(w, r.Run, AlertRecords(r.Alerts, r.Run.To), ReplayReports(r))
+}
+
+func (rw recordsWriter) WriteDiff(w io.Writer, d DiffResult) error {
+	return rw.render(w, d.Run, DiffRecords(d.LeftName, d.RightName, d.AllRows(), d.LeftTo, d.RightTo), Reports{})
+}
+
+// RenderJSON writes the run, its alert records and reports as a single JSON
+// document to w.
+func RenderJSON(w io.Writer, run Run, records []Record, reports Reports) error {
+	if records == nil {
+		records = []Record{}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(struct {
+		SchemaVersion int                  `json:"schemaVersion"`
+		Run           runJSON              `json:"run"`
+		Alerts        []Record             `json:"alerts"`
+		NearMisses    []NearMissRecord     `json:"nearMisses,omitzero"`
+		Notifications []NotificationRecord `json:"notifications,omitzero"`
+	}{SchemaVersion, newRunJSON(run), records, reports.NearMisses, reports.Notifications})
+}
+
+// RenderNDJSON writes the run followed by each of its alert records, near
+// misses and notifications as one JSON object per line to w. Each line has a
+// type, "run", "alert", "nearMiss" or "notification", and the schema version.
+func RenderNDJSON(w io.Writer, run Run, records []Record, reports Reports) error {
+	enc := json.NewEncoder(w)
+
+	if err := enc.Encode(struct {
+		SchemaVersion int     `json:"schemaVersion"`
+		Type          string  `json:"type"`
+		Run           runJSON `json:"run"`
+	}{SchemaVersion, "run", newRunJSON(run)}); err != nil {
+		return err
+	}
+
+	for _, r := range records {
+		if err := enc.Encode(struct {
+			SchemaVersion int    `json:"schemaVersion"`
+			Type          string `json:"type"`
+			Alert         Record `json:"alert"`
+		}{SchemaVersion, "alert", r}); err != nil {
+			return err
+		}
+	}
+
+	for _, nm := range reports.NearMisses {
+		if err := enc.Encode(struct {
+			SchemaVersion int            `json:"schemaVersion"`
+			Type          string         `json:"type"`
+			NearMiss      NearMissRecord `json:"nearMiss"`
+		}{SchemaVersion, "nearMiss", nm}); err != nil {
+			return err
+		}
+	}
+
+	for _, n := range reports.Notifications {
+		if err := enc.Encode(struct {
+			SchemaVersion int                `json:"schemaVersion"`
+			Type          string             `json:"type"`
+			Notification  NotificationRecord `json:"notification"`
+		}{SchemaVersion, "notification", n}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,262 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/steved/alertreplay/internal/alert"
+	"github.com/steved/alertreplay/internal/alertmanager"
+)
+
+func testRun(base time.Time) Run {
+	return Run{
+		Command:  "replay",
+		From:     base,
+		To:       base.Add(2 * time.Hour),
+		Interval: 30 * time.Second,
+		Rules: []RunRule{{
+			Datasource: "http://localhost:9090",
+			Alert:      "HighErrorRate",
+			Expr:       "errors > 1",
+			For:        5 * time.Minute,
+			From:       base,
+			To:         base.Add(2 * time.Hour),
+		}},
+	}
+}
+
+func TestRenderJSON(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	alerts := []alert.Alert{
+		{
+			OpenedAt:    base,
+			ResolvedAt:  new(base.Add(10 * time.Minute)),
+			Labels:      map[string]string{"alertname": "HighErrorRate", "job": "api"},
+			Annotations: map[string]string{"summary": "api errors"},
+			Values:      &alert.Values{Firing: 2, Peak: 5, Min: 1.5, Last: 1.5},
+			URL:         "http://localhost:9090/graph",
+		},
+		{
+			OpenedAt: base.Add(time.Hour),
+			Labels:   map[string]string{"alertname": "HighErrorRate", "job": "db"},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, RenderJSON(&buf, testRun(base), AlertRecords(alerts, base.Add(2*time.Hour)), Reports{}))
+
+	assert.JSONEq(t, `{
+		"schemaVersion": 1,
+		"run": {
+			"command": "replay",
+			"from": "2026-01-01T12:00:00Z",
+			"to": "2026-01-01T14:00:00Z",
+			"interval": "30s",
+			"rules": [{
+				"datasource": "http://localhost:9090",
+				"alert": "HighErrorRate",
+				"expr": "errors > 1",
+				"for": "5m0s",
+				"from": "2026-01-01T12:00:00Z",
+				"to": "2026-01-01T14:00:00Z"
+			}]
+		},
+		"alerts": [
+			{
+				"alertname": "HighErrorRate",
+				"labels": {"alertname": "HighErrorRate", "job": "api"},
+				"openedAt": "2026-01-01T12:00:00Z",
+				"resolvedAt": "2026-01-01T12:10:00Z",
+				"durationSeconds": 600,
+				"url": "http://localhost:9090/graph",
+				"values": {"firing": 2, "peak": 5, "min": 1.5, "last": 1.5},
+				"annotations": {"summary": "api errors"}
+			},
+			{
+				"alertname": "HighErrorRate",
+				"labels": {"alertname": "HighErrorRate", "job": "db"},
+				"openedAt": "2026-01-01T13:00:00Z",
+				"resolvedAt": null,
+				"durationSeconds": 3600
+			}
+		]
+	}`, buf.String())
+}
+
+func TestRenderJSON_noAlerts(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	require.NoError(t, RenderJSON(&buf, testRun(base), nil, Reports{}))
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	assert.Equal(t, []any{}, doc["alerts"])
+}
+
+func TestRenderJSON_reports(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	result := ReplayResult{
+		Run: testRun(base),
+		Alerts: []alert.Alert{{
+			PendingAt:       base.Add(-5 * time.Minute),
+			OpenedAt:        base,
+			ResolvedAt:      new(base.Add(20 * time.Minute)),
+			KeepFiringSince: new(base.Add(15 * time.Minute)),
+			Labels:          map[string]string{"alertname": "HighErrorRate", "job": "api"},
+			Silenced: &alert.Suppression{
+				Intervals: []alert.Interval{{Start: base, End: base.Add(5 * time.Minute)}},
+				Share:     0.25,
+			},
+			Inhibited: &alert.Suppression{
+				Intervals: []alert.Interval{{Start: base.Add(10 * time.Minute), End: base.Add(20 * time.Minute)}},
+				Share:     0.5,
+			},
+		}},
+		NearMisses: []alert.NearMiss{{
+			PendingAt: base.Add(time.Hour),
+			ClearedAt: base.Add(time.Hour + 3*time.Minute),
+			For:       5 * time.Minute,
+			Labels:    map[string]string{"alertname": "HighErrorRate", "job": "db"},
+		}},
+		WithNearMisses:    true,
+		WithNotifications: true,
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, writers[FormatJSON].WriteReplay(&buf, result))
+
+	var doc struct {
+		Alerts        []map[string]any `json:"alerts"`
+		NearMisses    []map[string]any `json:"nearMisses"`
+		Notifications []map[string]any `json:"notifications"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+
+	require.Len(t, doc.Alerts, 1)
+	assert.Equal(t, "2026-01-01T11:55:00Z", doc.Alerts[0]["pendingAt"])
+	assert.Equal(t, "2026-01-01T12:15:00Z", doc.Alerts[0]["keepFiringSince"])
+	assert.Equal(t, map[string]any{
+		"share":     0.25,
+		"intervals": []any{map[string]any{"start": "2026-01-01T12:00:00Z", "end": "2026-01-01T12:05:00Z"}},
+	}, doc.Alerts[0]["silenced"])
+	assert.Equal(t, 0.5, doc.Alerts[0]["inhibited"].(map[string]any)["share"])
+
+	assert.Equal(t, []map[string]any{{
+		"alertname":      "HighErrorRate",
+		"labels":         map[string]any{"alertname": "HighErrorRate", "job": "db"},
+		"pendingAt":      "2026-01-01T13:00:00Z",
+		"clearedAt":      "2026-01-01T13:03:00Z",
+		"pendingSeconds": 180.0,
+		"for":            "5m0s",
+	}}, doc.NearMisses)
+	assert.NotNil(t, doc.Notifications, "requested reports are written even when empty")
+	assert.Empty(t, doc.Notifications)
+
+	buf.Reset()
+	result.WithNearMisses, result.WithNotifications = false, false
+	require.NoError(t, writers[FormatJSON].WriteReplay(&buf, result))
+	assert.NotContains(t, buf.String(), "nearMisses")
+	assert.NotContains(t, buf.String(), "notifications")
+}
+
+func TestRenderJSON_nonFinite(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	alerts := []alert.Alert{{
+		OpenedAt: base,
+		Labels:   map[string]string{"alertname": "A"},
+		Values:   &alert.Values{Firing: math.Inf(1), Peak: math.Inf(1), Min: math.Inf(-1), Last: math.NaN()},
+	}}
+
+	var buf bytes.Buffer
+	require.NoError(t, RenderNDJSON(&buf, testRun(base), AlertRecords(alerts, base.Add(time.Hour)), Reports{}))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[1], `"values":{"firing":"+Inf","peak":"+Inf","min":"-Inf","last":"NaN"}`)
+
+	var parsed struct {
+		Alert Record `json:"alert"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &parsed))
+	assert.True(t, math.IsInf(float64(parsed.Alert.Values.Firing), 1))
+	assert.True(t, math.IsInf(float64(parsed.Alert.Values.Min), -1))
+	assert.True(t, math.IsNaN(float64(parsed.Alert.Values.Last)))
+}
+
+func TestRenderNDJSON(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	left := []alert.Alert{{OpenedAt: base, Labels: map[string]string{"alertname": "A", "job": "api"}}}
+	right := []alert.Alert{
+		{OpenedAt: base.Add(time.Minute), Labels: map[string]string{"alertname": "A", "job": "api"}},
+		{OpenedAt: base.Add(time.Hour), Labels: map[string]string{"alertname": "A", "job": "db"}},
+	}
+	rows := alert.Diff(left, right, alert.DefaultMatcher)
+
+	var buf bytes.Buffer
+	records := DiffRecords("old.yaml", "new.yaml", rows, base.Add(2*time.Hour), base.Add(2*time.Hour))
+	reports := Reports{Notifications: []NotificationRecord{{
+		SentAt:   "2026-01-01T12:00:30Z",
+		Receiver: "team",
+		Reason:   alertmanager.ReasonFiring,
+		Firing:   1,
+		Group:    map[string]string{"alertname": "A"},
+	}}}
+	require.NoError(t, RenderNDJSON(&buf, testRun(base), records, reports))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 5)
+	assert.JSONEq(t, `{
+		"schemaVersion": 1,
+		"type": "notification",
+		"notification": {"sentAt": "2026-01-01T12:00:30Z", "receiver": "team", "reason": "firing", "firing": 1, "resolved": 0, "group": {"alertname": "A"}}
+	}`, lines[4])
+	lines = lines[:4]
+
+	type line struct {
+		SchemaVersion int            `json:"schemaVersion"`
+		Type          string         `json:"type"`
+		Run           map[string]any `json:"run"`
+		Alert         Record         `json:"alert"`
+	}
+
+	var parsed []line
+	for _, l := range lines {
+		var p line
+		require.NoError(t, json.Unmarshal([]byte(l), &p))
+		assert.Equal(t, SchemaVersion, p.SchemaVersion)
+		parsed = append(parsed, p)
+	}
+
+	assert.Equal(t, "run", parsed[0].Type)
+	assert.Equal(t, "replay", parsed[0].Run["command"])
+
+	for i, want := range []struct {
+		source string
+		kind   alert.DiffKind
+		row    int
+		job    string
+	}{
+		{"old.yaml", alert.DiffMatched, 0, "api"},
+		{"new.yaml", alert.DiffMatched, 0, "api"},
+		{"new.yaml", alert.DiffOnlyRight, 1, "db"},
+	} {
+		r := parsed[i+1]
+		assert.Equal(t, "alert", r.Type)
+		assert.Equal(t, want.source, r.Alert.Source)
+		assert.Equal(t, want.kind, r.Alert.Diff)
+		assert.Equal(t, want.row, *r.Alert.DiffRow)
+		assert.Equal(t, want.job, r.Alert.Labels["job"])
+	}
+}
@@ -3,10 +3,13 @@ package output
 import (
 	"fmt"
 	"io"
+	"maps"
 	"os"
 	"os/exec"
 	"runtime"
 	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/table"
@@ -18,6 +21,7 @@ import (
 	"golang.org/x/term"
 
 	"github.com/steved/alertreplay/internal/alert"
+	"github.com/steved/alertreplay/internal/alertmanager"
 )
 
 func init() {
@@ -31,12 +35,60 @@ var baseStyle = lipgloss.NewStyle().
 	BorderStyle(lipgloss.NormalBorder()).
 	BorderForeground(lipgloss.Color("240"))
 
+var detailsStyle = lipgloss.NewStyle().
+	PaddingLeft(2).
+	Foreground(lipgloss.Color("245"))
+
+// rowStyle styles whole rows of an interactive table. A row is marked by
+// prefixing its first cell with as many zero width rowMarkers as the value of
+// its style.
+type rowStyle uint8
+
+const (
+	rowInhibited rowStyle = 1 << iota
+	rowOnlyLeft
+	rowOnlyRight
+	rowChanged
+)
+
+const rowMarker = "\u200b"
+
+func (s rowStyle) marker() string {
+	return strings.Repeat(rowMarker, int(s))
+}
+
+func (s rowStyle) style() lipgloss.Style {
+	style := lipgloss.NewStyle().Faint(s&rowInhibited != 0)
+
+	switch {
+	case s&rowOnlyLeft != 0:
+		style = style.Foreground(lipgloss.Color("1"))
+	case s&rowOnlyRight != 0:
+		style = style.Foreground(lipgloss.Color("2"))
+	case s&rowChanged != 0:
+		style = style.Foreground(lipgloss.Color("3"))
+	}
+
+	return style
+}
+
+// maxDetailsHeight caps the number of lines reserved below the table for the
+// annotations of the selected alert.
+const maxDetailsHeight = 6
+
 type tableModel struct {
-	table    table.Model
-	links    []string
-	viewport viewport.Model
-	width    int
-	height   int
+	table table.Model
+	links []string
+	// selectable tables quit on enter and record the row in selected,
+	// instead of opening its link.
+	selectable    bool
+	selected      int
+	help          string
+	details       []string
+	detailsHeight int
+	viewport      viewport.Model
+	width         int
+	height        int
 }
 
 func (m tableModel) Init() tea.Cmd { return nil }
@@ -49,6 +101,11 @@ func (m tableModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 		case "enter":
 			idx := m.table.Cursor()
+			if m.selectable {
+				m.selected = idx
+				return m, tea.Quit
+			}
+
 			if idx >= 0 && idx < len(m.links) {
 				openBrowser(m.links[idx])
 			}
@@ -71,15 +128,29 @@ func (m tableModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m *tableModel) reflow() {
-	m.table.SetHeight(calcTableHeight(m.height))
+	m.table.SetHeight(calcTableHeight(m.height - m.detailsHeight))
 	m.viewport.Width = m.width
 	m.viewport.Height = m.height
 	m.refreshContent()
 }
 
 func (m *tableModel) refreshContent() {
-	content := baseStyle.Render(m.table.View()) +
-		"\n  Press enter to open UI, ←/→ to scroll, q to quit\n"
+	content := baseStyle.Render(styleRows(m.table.View()))
+
+	if m.detailsHeight > 0 {
+		var details string
+		if idx := m.table.Cursor(); idx >= 0 && idx < len(m.details) {
+			details = m.details[idx]
+		}
+
+		content += "\n" + detailsStyle.
+			Width(max(m.width, 1)).
+			Height(m.detailsHeight).
+			MaxHeight(m.detailsHeight).
+			Render(details)
+	}
+
+	content += "\n  " + m.help + "\n"
 	m.viewport.SetContent(content)
 }
 
@@ -87,55 +158,91 @@ func (m tableModel) View() string {
 	return m.viewport.View()
 }
 
+// styleRows styles the lines of view holding marked rows. The table counts
+// escape sequences in cells towards their width, so the whole line is styled
+// once rendered.
+func styleRows(view string) string {
+	if !strings.Contains(view, rowMarker) {
+		return view
+	}
+
+	lines := strings.Split(view, "\n")
+	for i, line := range lines {
+		if n := strings.Count(line, rowMarker); n > 0 {
+			lines[i] = rowStyle(n).style().Render(strings.ReplaceAll(line, rowMarker, ""))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
 func newTableModel(
 	alerts []alert.Alert,
 	termWidth int,
 	termHeight int,
 ) tableModel {
-	hasSourceCol := slices.ContainsFunc(alerts, func(ar alert.Alert) bool { return ar.Source != "" })
-
-	rows := make([]table.Row, 0, len(alerts))
-	links := make([]string, 0, len(alerts))
-	for _, ar := range alerts {
-		resolvedStr := ""
-		durationStr := ""
-		if ar.ResolvedAt != nil {
-			resolvedStr = ar.ResolvedAt.UTC().Format(outputTimeFormat)
-			durationStr = ar.ResolvedAt.Sub(ar.OpenedAt).Round(time.Second).String()
-		} else {
-			resolvedStr = "UNRESOLVED"
-			durationStr = "--"
-		}
+	return newItemsModel(alerts, alertColumns(alerts), func(ar alert.Alert) alert.Alert { return ar }, nil, termWidth, termHeight)
+}
 
-		var row table.Row
-		if hasSourceCol {
-			row = table.Row{
-				ar.Source,
-				ar.OpenedAt.UTC().Format(outputTimeFormat),
-				resolvedStr,
-				durationStr,
-				alert.FormatLabels(ar.Labels),
-			}
-		} else {
-			row = table.Row{
-				ar.OpenedAt.UTC().Format(outputTimeFormat),
-				resolvedStr,
-				durationStr,
-				alert.FormatLabels(ar.Labels),
-			}
+// newItemsModel creates a table of items, each showing the URL and
+// annotations of the alert returned by alertOf. Rows are styled by styleOf, if
+// set, and dimmed when their alert was inhibited.
+func newItemsModel[T any](
+	items []T,
+	cols []column[T],
+	alertOf func(T) alert.Alert,
+	styleOf func(T) rowStyle,
+	termWidth int,
+	termHeight int,
+) tableModel {
+	var (
+		rows          = make([]table.Row, 0, len(items))
+		links         = make([]string, 0, len(items))
+		details       = make([]string, 0, len(items))
+		detailsHeight int
+	)
+
+	for _, item := range items {
+		ar := alertOf(item)
+
+		var style rowStyle
+		if styleOf != nil {
+			style = styleOf(item)
+		}
+		if ar.Inhibited != nil {
+			style |= rowInhibited
 		}
 
+		row := columnValues(cols, item)
+		row[0] = style.marker() + row[0]
+
 		rows = append(rows, row)
 		links = append(links, ar.URL)
+
+		detail := formatDetails(ar.Annotations)
+		details = append(details, detail)
+		if detail != "" {
+			detailsHeight = max(detailsHeight, strings.Count(detail, "\n")+1)
+		}
 	}
 
-	columns := buildColumns(termWidth, hasSourceCol)
+	detailsHeight = min(detailsHeight, maxDetailsHeight)
+
+	m := newModel(buildColumns(termWidth, cols), rows, detailsHeight, termWidth, termHeight)
+	m.links = links
+	m.details = details
+	m.help = "Press enter to open UI, ←/→ to scroll, q to quit"
+	m.refreshContent()
+
+	return m
+}
 
+func newModel(columns []table.Column, rows []table.Row, detailsHeight, termWidth, termHeight int) tableModel {
 	t := table.New(
 		table.WithColumns(columns),
 		table.WithRows(rows),
 		table.WithFocused(true),
-		table.WithHeight(calcTableHeight(termHeight)),
+		table.WithHeight(calcTableHeight(termHeight-detailsHeight)),
 	)
 
 	s := table.DefaultStyles()
@@ -153,56 +260,239 @@ func newTableModel(
 	vp := viewport.New(termWidth, termHeight)
 	vp.SetHorizontalStep(8)
 
-	m := tableModel{
-		table:    t,
-		links:    links,
-		viewport: vp,
-		width:    termWidth,
-		height:   termHeight,
+	return tableModel{
+		table:         t,
+		selected:      -1,
+		detailsHeight: detailsHeight,
+		viewport:      vp,
+		width:         termWidth,
+		height:        termHeight,
 	}
-	m.refreshContent()
+}
 
-	return m
+func init() {
+	Register(FormatTable, tableWriter{})
+	Register(FormatMarkdown, markdownWriter{})
 }
 
-func PrintEvents(alerts []alert.Alert) error {
-	if len(alerts) == 0 {
+// tableWriter shows alerts in an interactive table, and the other reports as
+// markdown.
+type tableWriter struct{}
+
+func (tableWriter) WriteReplay(w io.Writer, r ReplayResult) error {
+	if len(r.Alerts) == 0 {
 		zlog.Info().Msg("No alert events found.")
-		return nil
+	} else {
+		termWidth, termHeight := terminalSize()
+
+		m := newTableModel(r.Alerts, termWidth, termHeight)
+		if _, err := tea.NewProgram(m, tea.WithOutput(w)).Run(); err != nil {
+			return fmt.Errorf("running table: %w", err)
+		}
 	}
 
-	if !term.IsTerminal(int(os.Stdout.Fd())) {
-		return RenderMarkdown(os.Stdout, alerts)
+	return renderReplayReports(w, r)
+}
+
+// markdownWriter writes alerts and reports as markdown tables.
+type markdownWriter struct{}
+
+func (markdownWriter) WriteReplay(w io.Writer, r ReplayResult) error {
+	if len(r.Alerts) == 0 {
+		zlog.Info().Msg("No alert events found.")
+	} else if err := RenderMarkdown(w, r.Alerts); err != nil {
+		return err
 	}
 
-	termWidth := 140
-	termHeight := 40
-	if w, h, err := term.GetSize(int(os.Stdout.Fd())); err == nil && w > 0 && h > 0 {
-		termWidth = w
-		termHeight = h
+	return renderReplayReports(w, r)
+}
+
+// renderReplayReports writes the near misses and notifications of a replay,
+// if reported, as markdown to w.
+func renderReplayReports(w io.Writer, r ReplayResult) error {
+	if r.WithNearMisses {
+		if len(r.NearMisses) == 0 {
+			zlog.Info().Msg("No near misses found.")
+		} else if err := RenderNearMisses(w, r.NearMisses); err != nil {
+			return err
+		}
 	}
 
-	m := newTableModel(alerts, termWidth, termHeight)
-	p := tea.NewProgram(m)
-	if _, err := p.Run(); err != nil {
-		return fmt.Errorf("running table: %w", err)
+	if r.WithNotifications {
+		if len(r.Notifications) == 0 {
+			zlog.Info().Msg("No notifications would have been sent.")
+		} else if err := RenderNotifications(w, r.Notifications); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-// RenderMarkdown writes alerts as a markdown table to w.
+func terminalSize() (int, int) {
+	if w, h, err := term.GetSize(int(os.Stdout.Fd())); err == nil && w > 0 && h > 0 {
+		return w, h
+	}
+
+	return 140, 40
+}
+
+// RenderMarkdown writes alerts as a markdown table to w. Inhibited alerts are
+// struck through.
 func RenderMarkdown(w io.Writer, alerts []alert.Alert) error {
-	headers := []string{"Opened", "Resolved", "Duration", "Labels", "URL"}
+	cols := alertColumns(alerts)
 
-	hasSourceCol := slices.ContainsFunc(alerts, func(alert alert.Alert) bool { return alert.Source != "" })
-	if hasSourceCol {
-		headers = append([]string{"Source"}, headers...)
+	if slices.ContainsFunc(alerts, func(ar alert.Alert) bool { return len(ar.Annotations) > 0 }) {
+		cols = append(cols, column[alert.Alert]{
+			title: "Annotations",
+			value: func(ar alert.Alert) string { return alert.FormatAnnotations(ar.Annotations) },
+		})
 	}
 
-	re := lipgloss.NewRenderer(w)
-	cellStyle := re.NewStyle().Padding(0, 1)
-	t := lipglosstable.New().
+	cols = append(cols, column[alert.Alert]{
+		title: "URL",
+		value: func(ar alert.Alert) string { return ar.URL },
+	})
+
+	return renderMarkdownTable(w, cols, alerts, func(ar alert.Alert) alert.Alert { return ar })
+}
+
+// renderMarkdownTable writes items as a markdown table to w, striking through
+// the rows whose alert, as returned by alertOf, was inhibited.
+func renderMarkdownTable[T any](w io.Writer, cols []column[T], items []T, alertOf func(T) alert.Alert) error {
+	headers := make([]string, 0, len(cols))
+	for _, col := range cols {
+		headers = append(headers, col.title)
+	}
+
+	t := newMarkdownTable(w, headers...)
+	for _, item := range items {
+		values := columnValues(cols, item)
+		if alertOf(item).Inhibited != nil {
+			for i, value := range values {
+				if value != "" {
+					values[i] = "~~" + value + "~~"
+				}
+			}
+		}
+
+		t.Row(values...)
+	}
+
+	_, err := fmt.Fprintln(w, t.Render())
+
+	return err
+}
+
+func columnValues[T any](cols []column[T], item T) []string {
+	values := make([]string, 0, len(cols))
+	for _, col := range cols {
+		values = append(values, col.value(item))
+	}
+
+	return values
+}
+
+// formatDetails renders annotations one per line for the details pane, with
+// multi-line values collapsed so each annotation keeps a single line.
+func formatDetails(annotations map[string]string) string {
+	var (
+		keys  = slices.Sorted(maps.Keys(annotations))
+		lines = make([]string, 0, len(keys))
+	)
+
+	for _, k := range keys {
+		value := strings.Join(strings.Fields(annotations[k]), " ")
+		lines = append(lines, k+": "+value)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// RenderNearMisses writes near misses as a markdown table to w.
+func RenderNearMisses(w io.Writer, nearMisses []alert.NearMiss) error {
+	showAlert := multipleAlertNames(nearMisses, func(nm alert.NearMiss) map[string]string { return nm.Labels })
+
+	headers := []string{"Pending", "Cleared", "Pending For", "For", "Closeness", "Labels"}
+	if showAlert {
+		headers = slices.Insert(headers, 0, "Alert")
+	}
+
+	t := newMarkdownTable(w, headers...)
+	for _, nm := range nearMisses {
+		row := []string{
+			nm.PendingAt.UTC().Format(outputTimeFormat),
+			nm.ClearedAt.UTC().Format(outputTimeFormat),
+			nm.Duration().Round(time.Second).String(),
+			nm.For.String(),
+			fmt.Sprintf("%.0f%%", nm.Closeness()*100),
+			alert.FormatLabels(nm.Labels),
+		}
+		if showAlert {
+			row = slices.Insert(row, 0, nm.Labels["alertname"])
+		}
+
+		t.Row(row...)
+	}
+
+	_, err := fmt.Fprintln(w, t.Render())
+
+	return err
+}
+
+// RenderNotifications writes a per receiver summary of notifications and the
+// notifications themselves as markdown tables to w.
+func RenderNotifications(w io.Writer, notifications []alertmanager.Notification) error {
+	type summary struct {
+		total    int
+		byReason map[alertmanager.Reason]int
+	}
+
+	summaries := make(map[string]*summary)
+	for _, n := range notifications {
+		s, ok := summaries[n.Receiver]
+		if !ok {
+			s = &summary{byReason: make(map[alertmanager.Reason]int)}
+			summaries[n.Receiver] = s
+		}
+
+		s.total++
+		s.byReason[n.Reason]++
+	}
+
+	summaryTable := newMarkdownTable(w, "Receiver", "Notifications", "Firing", "Resolved", "Repeat")
+	for _, receiver := range slices.Sorted(maps.Keys(summaries)) {
+		s := summaries[receiver]
+		summaryTable.Row(
+			receiver,
+			strconv.Itoa(s.total),
+			strconv.Itoa(s.byReason[alertmanager.ReasonFiring]),
+			strconv.Itoa(s.byReason[alertmanager.ReasonResolved]),
+			strconv.Itoa(s.byReason[alertmanager.ReasonRepeat]),
+		)
+	}
+
+	t := newMarkdownTable(w, "Sent", "Receiver", "Reason", "Firing", "Resolved", "Group")
+	for _, n := range notifications {
+		t.Row(
+			n.Time.UTC().Format(outputTimeFormat),
+			n.Receiver,
+			string(n.Reason),
+			strconv.Itoa(n.Firing),
+			strconv.Itoa(n.Resolved),
+			alert.FormatGroupLabels(n.Group),
+		)
+	}
+
+	_, err := fmt.Fprintf(w, "%s\n\n%s\n", summaryTable.Render(), t.Render())
+
+	return err
+}
+
+func newMarkdownTable(w io.Writer, headers ...string) *lipglosstable.Table {
+	cellStyle := lipgloss.NewRenderer(w).NewStyle().Padding(0, 1)
+
+	return lipglosstable.New().
 		Headers(headers...).
 		Border(lipgloss.MarkdownBorder()).
 		BorderTop(false).
@@ -210,25 +500,22 @@ func RenderMarkdown(w io.Writer, alerts []alert.Alert) error {
 		StyleFunc(func(row, col int) lipgloss.Style {
 			return cellStyle
 		})
+}
 
-	for _, ar := range alerts {
-		resolvedStr := "UNRESOLVED"
-		durationStr := "--"
-		if ar.ResolvedAt != nil {
-			resolvedStr = ar.ResolvedAt.UTC().Format(outputTimeFormat)
-			durationStr = ar.ResolvedAt.Sub(ar.OpenedAt).Round(time.Second).String()
-		}
-
-		if hasSourceCol {
-			t.Row(ar.Source, ar.OpenedAt.UTC().Format(outputTimeFormat), resolvedStr, durationStr, alert.FormatLabels(ar.Labels), ar.URL)
-		} else {
-			t.Row(ar.OpenedAt.UTC().Format(outputTimeFormat), resolvedStr, durationStr, alert.FormatLabels(ar.Labels), ar.URL)
+// multipleAlertNames reports whether items come from more than one alert rule,
+// in which case the alert name is shown since FormatLabels omits it.
+func multipleAlertNames[T any](items []T, labels func(T) map[string]string) bool {
+	var first string
+	for i, item := range items {
+		name := labels(item)["alertname"]
+		if i == 0 {
+			first = name
+		} else if name != first {
+			return true
 		}
 	}
 
-	_, err := fmt.Fprintln(w, t.Render())
-
-	return err
+	return false
 }
 
 func openBrowser(url string) {
@@ -255,41 +542,179 @@ func calcTableHeight(termHeight int) int {
 }
 
 const (
-	colWidthSource   = 30
-	colWidthOpened   = 21
-	colWidthResolved = 21
-	colWidthDuration = 12
+	colWidthSource      = 30
+	colWidthAlert       = 24
+	colWidthPending     = 21
+	colWidthOpened      = 21
+	colWidthResolved    = 21
+	colWidthDuration    = 12
+	colWidthKeptFiring  = 12
+	colWidthValue       = 10
+	colWidthSuppressed  = 10
+	minColWidthFlexible = 20
 )
 
-func buildColumns(termWidth int, hasSourceCol bool) []table.Column {
-	var (
-		fixedWidth = colWidthOpened + colWidthResolved + colWidthDuration
-		numCols    = 4
+// column describes a single attribute of the items shown in the table and
+// markdown output. A zero width marks the column that expands to fill the
+// terminal.
+type column[T any] struct {
+	title string
+	width int
+	value func(T) string
+}
+
+func alertColumns(alerts []alert.Alert) []column[alert.Alert] {
+	var cols []column[alert.Alert]
+
+	if slices.ContainsFunc(alerts, func(ar alert.Alert) bool { return ar.Source != "" }) {
+		cols = append(cols, column[alert.Alert]{
+			title: "Source",
+			width: colWidthSource,
+			value: func(ar alert.Alert) string { return ar.Source },
+		})
+	}
+
+	if multipleAlertNames(alerts, func(ar alert.Alert) map[string]string { return ar.Labels }) {
+		cols = append(cols, column[alert.Alert]{
+			title: "Alert",
+			width: colWidthAlert,
+			value: func(ar alert.Alert) string { return ar.Labels["alertname"] },
+		})
+	}
+
+	if slices.ContainsFunc(alerts, func(ar alert.Alert) bool {
+		return !ar.PendingAt.IsZero() && ar.PendingAt.Before(ar.OpenedAt)
+	}) {
+		cols = append(cols, column[alert.Alert]{
+			title: "Pending",
+			width: colWidthPending,
+			value: func(ar alert.Alert) string {
+				if ar.PendingAt.IsZero() {
+					return ""
+				}
+				return ar.PendingAt.UTC().Format(outputTimeFormat)
+			},
+		})
+	}
+
+	cols = append(cols,
+		column[alert.Alert]{
+			title: "Opened",
+			width: colWidthOpened,
+			value: func(ar alert.Alert) string { return ar.OpenedAt.UTC().Format(outputTimeFormat) },
+		},
+		column[alert.Alert]{
+			title: "Resolved",
+			width: colWidthResolved,
+			value: func(ar alert.Alert) string {
+				if ar.ResolvedAt == nil {
+					return "UNRESOLVED"
+				}
+				return ar.ResolvedAt.UTC().Format(outputTimeFormat)
+			},
+		},
+		column[alert.Alert]{
+			title: "Duration",
+			width: colWidthDuration,
+			value: func(ar alert.Alert) string {
+				if ar.ResolvedAt == nil {
+					return "--"
+				}
+				return ar.ResolvedAt.Sub(ar.OpenedAt).Round(time.Second).String()
+			},
+		},
 	)
 
-	if hasSourceCol {
-		fixedWidth += colWidthSource
-		numCols = 5
+	if slices.ContainsFunc(alerts, func(ar alert.Alert) bool { return ar.KeepFiringSince != nil }) {
+		cols = append(cols, column[alert.Alert]{
+			title: "Kept Firing",
+			width: colWidthKeptFiring,
+			value: func(ar alert.Alert) string {
+				if ar.KeepFiringSince == nil || ar.ResolvedAt == nil {
+					return ""
+				}
+				return ar.ResolvedAt.Sub(*ar.KeepFiringSince).Round(time.Second).String()
+			},
+		})
 	}
 
-	paddingWidth := numCols * 2
-	labelsWidth := termWidth - baseStyle.GetHorizontalFrameSize() - paddingWidth - fixedWidth
-	labelsWidth = max(labelsWidth, 20)
+	if slices.ContainsFunc(alerts, func(ar alert.Alert) bool { return ar.Inhibited != nil }) {
+		cols = append(cols, suppressionColumn("Inhibited", func(ar alert.Alert) *alert.Suppression { return ar.Inhibited }))
+	}
 
-	if hasSourceCol {
-		return []table.Column{
-			{Title: "Source", Width: colWidthSource},
-			{Title: "Opened", Width: colWidthOpened},
-			{Title: "Resolved", Width: colWidthResolved},
-			{Title: "Duration", Width: colWidthDuration},
-			{Title: "Labels", Width: labelsWidth},
-		}
+	if slices.ContainsFunc(alerts, func(ar alert.Alert) bool { return ar.Silenced != nil }) {
+		cols = append(cols, suppressionColumn("Silenced", func(ar alert.Alert) *alert.Suppression { return ar.Silenced }))
 	}
 
-	return []table.Column{
-		{Title: "Opened", Width: colWidthOpened},
-		{Title: "Resolved", Width: colWidthResolved},
-		{Title: "Duration", Width: colWidthDuration},
-		{Title: "Labels", Width: labelsWidth},
+	if slices.ContainsFunc(alerts, func(ar alert.Alert) bool { return ar.Values != nil }) {
+		cols = append(cols,
+			valueColumn("Value", func(v alert.Values) float64 { return v.Firing }),
+			valueColumn("Peak", func(v alert.Values) float64 { return v.Peak }),
+			valueColumn("Min", func(v alert.Values) float64 { return v.Min }),
+			valueColumn("Last", func(v alert.Values) float64 { return v.Last }),
+		)
 	}
+
+	cols = append(cols, column[alert.Alert]{
+		title: "Labels",
+		value: func(ar alert.Alert) string { return alert.FormatLabels(ar.Labels) },
+	})
+
+	return cols
+}
+
+// suppressionColumn shows the share of an alert's duration that Alertmanager
+// suppressed it.
+func suppressionColumn(title string, suppression func(alert.Alert) *alert.Suppression) column[alert.Alert] {
+	return column[alert.Alert]{
+		title: title,
+		width: colWidthSuppressed,
+		value: func(ar alert.Alert) string {
+			s := suppression(ar)
+			if s == nil {
+				return ""
+			}
+			return fmt.Sprintf("%.0f%%", s.Share*100)
+		},
+	}
+}
+
+func valueColumn(title string, field func(alert.Values) float64) column[alert.Alert] {
+	return column[alert.Alert]{
+		title: title,
+		width: colWidthValue,
+		value: func(ar alert.Alert) string {
+			if ar.Values == nil {
+				return ""
+			}
+			return formatValue(field(*ar.Values))
+		},
+	}
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', 4, 64)
+}
+
+func buildColumns[T any](termWidth int, cols []column[T]) []table.Column {
+	fixedWidth := 0
+	for _, col := range cols {
+		fixedWidth += col.width
+	}
+
+	paddingWidth := len(cols) * 2
+	flexibleWidth := termWidth - baseStyle.GetHorizontalFrameSize() - paddingWidth - fixedWidth
+	flexibleWidth = max(flexibleWidth, minColWidthFlexible)
+
+	columns := make([]table.Column, 0, len(cols))
+	for _, col := range cols {
+		width := col.width
+		if width == 0 {
+			width = flexibleWidth
+		}
+
+		columns = append(columns, table.Column{Title: col.title, Width: width})
+	}
+
+	return columns
 }
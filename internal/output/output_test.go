@@ -1,9 +1,18 @@
 package output
 
 import (
+	"bytes"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/steved/alertreplay/internal/alert"
+	"github.com/steved/alertreplay/internal/alertmanager"
 )
 
 func TestCalcTableHeight(t *testing.T) {
@@ -37,53 +46,331 @@ func TestCalcTableHeight(t *testing.T) {
 }
 
 func TestBuildColumns(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
 	for _, tt := range []struct {
-		name         string
-		termWidth    int
-		hasSourceCol bool
-		wantCols     int
-		wantFirst    string
+		name      string
+		termWidth int
+		alerts    []alert.Alert
+		wantTitle []string
+		wantWidth []int
 	}{
 		{
-			name:         "without source column",
-			termWidth:    140,
-			hasSourceCol: false,
-			wantCols:     4,
-			wantFirst:    "Opened",
+			name:      "without source column",
+			termWidth: 140,
+			alerts:    []alert.Alert{{OpenedAt: base}},
+			wantTitle: []string{"Opened", "Resolved", "Duration", "Labels"},
+			wantWidth: []int{colWidthOpened, colWidthResolved, colWidthDuration},
+		},
+		{
+			name:      "with source column",
+			termWidth: 140,
+			alerts:    []alert.Alert{{OpenedAt: base, Source: "rules.yaml"}},
+			wantTitle: []string{"Source", "Opened", "Resolved", "Duration", "Labels"},
+			wantWidth: []int{colWidthSource, colWidthOpened, colWidthResolved, colWidthDuration},
+		},
+		{
+			name:      "with kept firing column",
+			termWidth: 140,
+			alerts: []alert.Alert{{
+				OpenedAt:        base,
+				ResolvedAt:      new(base.Add(10 * time.Minute)),
+				KeepFiringSince: new(base.Add(5 * time.Minute)),
+			}},
+			wantTitle: []string{"Opened", "Resolved", "Duration", "Kept Firing", "Labels"},
+			wantWidth: []int{colWidthOpened, colWidthResolved, colWidthDuration, colWidthKeptFiring},
 		},
 		{
-			name:         "with source column",
-			termWidth:    140,
-			hasSourceCol: true,
-			wantCols:     5,
-			wantFirst:    "Source",
+			name:      "with pending column",
+			termWidth: 140,
+			alerts:    []alert.Alert{{PendingAt: base, OpenedAt: base.Add(5 * time.Minute)}},
+			wantTitle: []string{"Pending", "Opened", "Resolved", "Duration", "Labels"},
+			wantWidth: []int{colWidthPending, colWidthOpened, colWidthResolved, colWidthDuration},
 		},
 		{
-			name:         "narrow terminal still has minimum labels width",
-			termWidth:    50,
-			hasSourceCol: false,
-			wantCols:     4,
+			name:      "no pending column when alerts fire immediately",
+			termWidth: 140,
+			alerts:    []alert.Alert{{PendingAt: base, OpenedAt: base}},
+			wantTitle: []string{"Opened", "Resolved", "Duration", "Labels"},
+			wantWidth: []int{colWidthOpened, colWidthResolved, colWidthDuration},
+		},
+		{
+			name:      "with value columns",
+			termWidth: 140,
+			alerts:    []alert.Alert{{OpenedAt: base, Values: &alert.Values{Firing: 1, Peak: 2, Min: 1, Last: 1}}},
+			wantTitle: []string{"Opened", "Resolved", "Duration", "Value", "Peak", "Min", "Last", "Labels"},
+			wantWidth: []int{colWidthOpened, colWidthResolved, colWidthDuration, colWidthValue, colWidthValue, colWidthValue, colWidthValue},
+		},
+		{
+			name:      "narrow terminal still has minimum labels width",
+			termWidth: 50,
+			alerts:    []alert.Alert{{OpenedAt: base}},
+			wantTitle: []string{"Opened", "Resolved", "Duration", "Labels"},
+			wantWidth: []int{colWidthOpened, colWidthResolved, colWidthDuration},
 		},
 	} {
 		t.Run(tt.name, func(t *testing.T) {
-			cols := buildColumns(tt.termWidth, tt.hasSourceCol)
-			assert.Len(t, cols, tt.wantCols)
-			if tt.wantFirst != "" {
-				assert.Equal(t, tt.wantFirst, cols[0].Title)
+			cols := buildColumns(tt.termWidth, alertColumns(tt.alerts))
+			require.Len(t, cols, len(tt.wantTitle))
+
+			for i, col := range cols {
+				assert.Equal(t, tt.wantTitle[i], col.Title)
+				if i < len(tt.wantWidth) {
+					assert.Equal(t, tt.wantWidth[i], col.Width)
+				}
 			}
 
-			if tt.hasSourceCol {
-				assert.Equal(t, colWidthSource, cols[0].Width)
-				assert.Equal(t, colWidthOpened, cols[1].Width)
-				assert.Equal(t, colWidthResolved, cols[2].Width)
-				assert.Equal(t, colWidthDuration, cols[3].Width)
-				assert.GreaterOrEqual(t, cols[4].Width, 20)
-			} else {
-				assert.Equal(t, colWidthOpened, cols[0].Width)
-				assert.Equal(t, colWidthResolved, cols[1].Width)
-				assert.Equal(t, colWidthDuration, cols[2].Width)
-				assert.GreaterOrEqual(t, cols[3].Width, 20)
+			assert.GreaterOrEqual(t, cols[len(cols)-1].Width, minColWidthFlexible)
+		})
+	}
+}
+
+func TestRenderMarkdown_keptFiring(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	err := RenderMarkdown(&buf, []alert.Alert{
+		{
+			OpenedAt:        base,
+			ResolvedAt:      new(base.Add(20 * time.Minute)),
+			KeepFiringSince: new(base.Add(5 * time.Minute)),
+			Labels:          map[string]string{"job": "api"},
+		},
+		{
+			OpenedAt:   base.Add(time.Hour),
+			ResolvedAt: new(base.Add(time.Hour + 5*time.Minute)),
+			Labels:     map[string]string{"job": "server"},
+		},
+	})
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 4)
+	assert.Contains(t, lines[0], "Kept Firing")
+	assert.Contains(t, lines[2], "15m0s")
+	assert.NotContains(t, lines[3], "15m0s")
+}
+
+func TestRenderMarkdown_annotations(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	err := RenderMarkdown(&buf, []alert.Alert{
+		{
+			OpenedAt:    base,
+			Labels:      map[string]string{"job": "api"},
+			Annotations: map[string]string{"summary": "api is down"},
+		},
+	})
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[0], "Annotations")
+	assert.Contains(t, lines[2], `summary="api is down"`)
+}
+
+func TestFormatDetails(t *testing.T) {
+	assert.Empty(t, formatDetails(nil))
+	assert.Equal(t,
+		"description: first line second line\nsummary: api is down",
+		formatDetails(map[string]string{
+			"summary":     "api is down",
+			"description": "first line\n  second line\n",
+		}),
+	)
+}
+
+func TestNewTableModel_detailsHeight(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	m := newTableModel([]alert.Alert{{OpenedAt: base}}, 140, 40)
+	assert.Zero(t, m.detailsHeight)
+
+	m = newTableModel([]alert.Alert{
+		{OpenedAt: base},
+		{OpenedAt: base, Annotations: map[string]string{"summary": "a", "description": "b"}},
+	}, 140, 40)
+	assert.Equal(t, 2, m.detailsHeight)
+	assert.Equal(t, []string{"", "description: b\nsummary: a"}, m.details)
+}
+
+func TestRenderNearMisses(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	err := RenderNearMisses(&buf, []alert.NearMiss{
+		{
+			PendingAt: base,
+			ClearedAt: base.Add(4 * time.Minute),
+			For:       5 * time.Minute,
+			Labels:    map[string]string{"job": "api"},
+		},
+	})
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t,
+		[]string{"2026-01-01 12:00 UTC", "2026-01-01 12:04 UTC", "4m0s", "5m0s", "80%", `{job="api"}`},
+		splitMarkdownRow(lines[2]),
+	)
+}
+
+func splitMarkdownRow(line string) []string {
+	cells := strings.Split(strings.Trim(line, "|"), "|")
+	for i := range cells {
+		cells[i] = strings.TrimSpace(cells[i])
+	}
+	return cells
+}
+
+func TestRenderMarkdown_values(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	err := RenderMarkdown(&buf, []alert.Alert{
+		{
+			OpenedAt: base,
+			Labels:   map[string]string{"job": "api"},
+			Values:   &alert.Values{Firing: 0.0512, Peak: 1234567, Min: 0.05, Last: 0.25},
+		},
+		{
+			OpenedAt: base.Add(time.Hour),
+			Labels:   map[string]string{"job": "server"},
+		},
+	})
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 4)
+	assert.Equal(t,
+		[]string{"Opened", "Resolved", "Duration", "Value", "Peak", "Min", "Last", "Labels", "URL"},
+		splitMarkdownRow(lines[0]),
+	)
+	assert.Equal(t, []string{"0.0512", "1.235e+06", "0.05", "0.25"}, splitMarkdownRow(lines[2])[3:7])
+	assert.Equal(t, []string{"", "", "", ""}, splitMarkdownRow(lines[3])[3:7])
+}
+
+func TestRenderMarkdown_alertNames(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, tt := range []struct {
+		name      string
+		alerts    []alert.Alert
+		wantAlert bool
+	}{
+		{
+			name: "single alert rule",
+			alerts: []alert.Alert{
+				{OpenedAt: base, Labels: map[string]string{"alertname": "TargetDown", "job": "api"}},
+				{OpenedAt: base, Labels: map[string]string{"alertname": "TargetDown", "job": "db"}},
+			},
+		},
+		{
+			name: "multiple alert rules",
+			alerts: []alert.Alert{
+				{OpenedAt: base, Labels: map[string]string{"alertname": "TargetDown", "job": "api"}},
+				{OpenedAt: base, Labels: map[string]string{"alertname": "ManyTargetsDown"}},
+			},
+			wantAlert: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, RenderMarkdown(&buf, tt.alerts))
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			require.Len(t, lines, 4)
+
+			if !tt.wantAlert {
+				assert.NotContains(t, splitMarkdownRow(lines[0]), "Alert")
+				return
 			}
+
+			assert.Equal(t, "Alert", splitMarkdownRow(lines[0])[0])
+			assert.Equal(t, "TargetDown", splitMarkdownRow(lines[2])[0])
+			assert.Equal(t, "ManyTargetsDown", splitMarkdownRow(lines[3])[0])
 		})
 	}
 }
+
+func TestRenderMarkdown_inhibited(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	err := RenderMarkdown(&buf, []alert.Alert{
+		{
+			OpenedAt:  base,
+			Labels:    map[string]string{"job": "api"},
+			Inhibited: &alert.Suppression{Share: 0.25},
+		},
+		{
+			OpenedAt: base.Add(time.Hour),
+			Labels:   map[string]string{"job": "server"},
+		},
+	})
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 4)
+	assert.Equal(t, []string{"Opened", "Resolved", "Duration", "Inhibited", "Labels", "URL"}, splitMarkdownRow(lines[0]))
+	assert.Equal(t, "~~25%~~", splitMarkdownRow(lines[2])[3])
+	assert.Equal(t, `~~{job="api"}~~`, splitMarkdownRow(lines[2])[4])
+	assert.Empty(t, splitMarkdownRow(lines[3])[3])
+	assert.Equal(t, `{job="server"}`, splitMarkdownRow(lines[3])[4])
+}
+
+func TestNewTableModel_inhibited(t *testing.T) {
+	profile := lipgloss.ColorProfile()
+	lipgloss.SetColorProfile(termenv.ANSI)
+	t.Cleanup(func() { lipgloss.SetColorProfile(profile) })
+
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	m := newTableModel([]alert.Alert{
+		{OpenedAt: base, Labels: map[string]string{"job": "api"}},
+		{OpenedAt: base, Labels: map[string]string{"job": "server"}, Inhibited: &alert.Suppression{Share: 1}},
+	}, 140, 40)
+
+	view := styleRows(m.table.View())
+	assert.NotContains(t, view, rowMarker)
+
+	var api, server string
+	for line := range strings.Lines(view) {
+		switch {
+		case strings.Contains(line, `job="api"`):
+			api = line
+		case strings.Contains(line, `job="server"`):
+			server = line
+		}
+	}
+
+	assert.NotContains(t, api, "\x1b[2m")
+	assert.True(t, strings.HasPrefix(server, "\x1b[2m"), "inhibited row is dimmed: %q", server)
+	assert.Contains(t, server, "2026-01-01 12:00 UTC", "dimmed cells aren't truncated")
+}
+
+func TestRenderNotifications(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	err := RenderNotifications(&buf, []alertmanager.Notification{
+		{Time: base, Receiver: "pager", Group: map[string]string{"alertname": "Down"}, Reason: alertmanager.ReasonFiring, Firing: 2},
+		{Time: base.Add(4 * time.Hour), Receiver: "pager", Group: map[string]string{"alertname": "Down"}, Reason: alertmanager.ReasonRepeat, Firing: 2},
+		{Time: base.Add(5 * time.Hour), Receiver: "slack", Group: map[string]string{"alertname": "Down"}, Reason: alertmanager.ReasonResolved, Resolved: 2},
+	})
+	require.NoError(t, err)
+
+	tables := strings.Split(strings.TrimSpace(buf.String()), "\n\n")
+	require.Len(t, tables, 2)
+
+	summary := strings.Split(tables[0], "\n")
+	require.Len(t, summary, 4)
+	assert.Equal(t, []string{"pager", "2", "1", "0", "1"}, splitMarkdownRow(summary[2]))
+	assert.Equal(t, []string{"slack", "1", "0", "1", "0"}, splitMarkdownRow(summary[3]))
+
+	notifications := strings.Split(tables[1], "\n")
+	require.Len(t, notifications, 5)
+	assert.Equal(t, []string{"2026-01-01 12:00 UTC", "pager", "firing", "2", "0", `{alertname="Down"}`}, splitMarkdownRow(notifications[2]))
+}
@@ -0,0 +1,135 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/steved/alertreplay/internal/alert"
+	"github.com/steved/alertreplay/internal/alertmanager"
+)
+
+// Format is the output format of replay and diff results.
+type Format string
+
+const (
+	// FormatAuto selects FormatTable when writing to a terminal and
+	// FormatMarkdown otherwise.
+	FormatAuto     Format = "auto"
+	FormatTable    Format = "table"
+	FormatMarkdown Format = "markdown"
+	FormatJSON     Format = "json"
+	FormatNDJSON   Format = "ndjson"
+	FormatCSV      Format = "csv"
+	FormatTSV      Format = "tsv"
+	// FormatHTML writes a self-contained report with a timeline of the alerts
+	// and a chart of the expression values.
+	FormatHTML Format = "html"
+)
+
+// Writer writes the results of replay and diff commands in an output format.
+type Writer interface {
+	WriteReplay(w io.Writer, r ReplayResult) error
+	WriteDiff(w io.Writer, d DiffResult) error
+}
+
+// ReplayResult holds the alerts of a replay and what was reported about them.
+type ReplayResult struct {
+	Run    Run
+	Alerts []alert.Alert
+	// NearMisses are written when WithNearMisses is set.
+	NearMisses     []alert.NearMiss
+	WithNearMisses bool
+	// Notifications are written when WithNotifications is set.
+	Notifications     []alertmanager.Notification
+	WithNotifications bool
+	// Series are the values of the alert expression, if known.
+	Series []alert.Series
+}
+
+// DiffResult holds the diff of an alert rule, or of every alert rule of two
+// files, replayed on a left and a right side.
+type DiffResult struct {
+	Run       Run
+	LeftName  string
+	RightName string
+	// LeftTo and RightTo end the periods replayed on each side.
+	LeftTo  time.Time
+	RightTo time.Time
+	// Rows is the diff of a single alert rule.
+	Rows []alert.DiffRow
+	// Summaries diff every alert rule of two files, instead of Rows.
+	Summaries []DiffSummary
+	// LabelSetChanges are written when WithLabelSetChanges is set.
+	LabelSetChanges     []alert.LabelSetChange
+	WithLabelSetChanges bool
+	// LeftSeries and RightSeries are the values of the alert expression on
+	// each side, if known.
+	LeftSeries  []alert.Series
+	RightSeries []alert.Series
+}
+
+// AllRows returns the rows of the diff, or those of every summary.
+func (d DiffResult) AllRows() []alert.DiffRow {
+	if d.Summaries == nil {
+		return d.Rows
+	}
+
+	var rows []alert.DiffRow
+	for _, s := range d.Summaries {
+		rows = append(rows, s.Rows...)
+	}
+
+	return rows
+}
+
+var writers = make(map[Format]Writer)
+
+// reportsWriter is implemented by writers that may not write the near misses
+// and notifications of replays.
+type reportsWriter interface {
+	writesReports() bool
+}
+
+// WritesReports reports whether format writes the near misses and
+// notifications of replays, rather than only their alerts.
+func WritesReports(format Format) bool {
+	w, ok := writers[format].(reportsWriter)
+
+	return !ok || w.writesReports()
+}
+
+// Register makes a writer available for format. It panics if format is
+// already registered.
+func Register(format Format, w Writer) {
+	if _, ok := writers[format]; ok {
+		panic(fmt.Sprintf("output format %q is already registered", format))
+	}
+
+	writers[format] = w
+}
+
+// Formats returns FormatAuto followed by the registered formats.
+func Formats() []Format {
+	return append([]Format{FormatAuto}, slices.Sorted(maps.Keys(writers))...)
+}
+
+// NewWriter returns the writer of format, resolving FormatAuto by whether the
+// output is written to a terminal.
+func NewWriter(format Format, terminal bool) (Writer, error) {
+	if format == FormatAuto {
+		format = FormatMarkdown
+		if terminal {
+			format = FormatTable
+		}
+	}
+
+	w, ok := writers[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown output format %q", format)
+	}
+
+	return w, nil
+}
@@ -0,0 +1,91 @@
+package output
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/steved/alertreplay/internal/alert"
+)
+
+func TestFormats(t *testing.T) {
+	assert.Equal(t, []Format{
+		FormatAuto, FormatCSV, FormatHTML, FormatJSON, FormatMarkdown, FormatNDJSON, FormatTable, FormatTSV,
+	}, Formats())
+}
+
+func TestRegister_duplicate(t *testing.T) {
+	assert.PanicsWithValue(t, `output format "json" is already registered`, func() {
+		Register(FormatJSON, markdownWriter{})
+	})
+}
+
+func TestNewWriter(t *testing.T) {
+	w, err := NewWriter(FormatAuto, false)
+	require.NoError(t, err)
+	assert.Equal(t, markdownWriter{}, w)
+
+	w, err = NewWriter(FormatAuto, true)
+	require.NoError(t, err)
+	assert.Equal(t, tableWriter{}, w)
+
+	w, err = NewWriter(FormatTable, false)
+	require.NoError(t, err)
+	assert.Equal(t, tableWriter{}, w)
+
+	_, err = NewWriter("xml", false)
+	require.EqualError(t, err, `unknown output format "xml"`)
+}
+
+// nopWriter records the results passed to it.
+type nopWriter struct {
+	diffs []DiffResult
+}
+
+func (n *nopWriter) WriteReplay(io.Writer, ReplayResult) error { return nil }
+
+func (n *nopWriter) WriteDiff(_ io.Writer, d DiffResult) error {
+	n.diffs = append(n.diffs, d)
+	return nil
+}
+
+func TestRegister(t *testing.T) {
+	const format Format = "test"
+
+	nop := &nopWriter{}
+	Register(format, nop)
+	t.Cleanup(func() { delete(writers, format) })
+
+	assert.Contains(t, Formats(), format)
+
+	w, err := NewWriter(format, false)
+	require.NoError(t, err)
+	require.NoError(t, w.WriteDiff(io.Discard, DiffResult{LeftName: "left"}))
+	assert.Equal(t, []DiffResult{{LeftName: "left"}}, nop.diffs)
+}
+
+func TestMarkdownWriter_WriteDiff(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	left := []alert.Alert{{OpenedAt: base, Labels: map[string]string{"job": "api"}}}
+	right := []alert.Alert{{OpenedAt: base.Add(time.Hour), Labels: map[string]string{"job": "db"}}}
+
+	var buf bytes.Buffer
+	err := markdownWriter{}.WriteDiff(&buf, DiffResult{
+		LeftName:            "old",
+		RightName:           "new",
+		Rows:                alert.Diff(left, right, alert.DefaultMatcher),
+		LabelSetChanges:     alert.CompareLabelSets(left, right, alert.DefaultMatcher, base.Add(2*time.Hour), base.Add(2*time.Hour)),
+		WithLabelSetChanges: true,
+	})
+	require.NoError(t, err)
+
+	out := buf.String()
+	assert.Contains(t, out, "old Opened")
+	assert.Contains(t, out, "old Alerts")
+	assert.Contains(t, out, "Total")
+}
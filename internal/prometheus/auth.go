@@ -0,0 +1,69 @@
+package prometheus
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/prometheus/client_golang/api"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+)
+
+// tenantHeader selects the tenant on multi-tenant backends such as Cortex,
+// Mimir and Thanos.
+const tenantHeader = "X-Scope-OrgID"
+
+// Auth authenticates requests to the Prometheus API.
+type Auth struct {
+	Username    string
+	Password    string
+	BearerToken string
+	Tenant      string
+}
+
+// NewAuthAPIClient is NewAPIClient sending auth with every request.
+func NewAuthAPIClient(prometheusURL string, parallelism int, auth Auth) (*APIClient, error) {
+	if auth.BearerToken != "" && auth.Username != "" {
+		return nil, fmt.Errorf("set either basic auth or a bearer token")
+	}
+
+	client, err := api.NewClient(api.Config{
+		Address:      prometheusURL,
+		RoundTripper: authRoundTripper{auth: auth, next: api.DefaultRoundTripper},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating Prometheus client: %w", err)
+	}
+
+	return &APIClient{
+		api:          v1.NewAPI(client),
+		parallelism:  parallelism,
+		queryTimeout: defaultQueryTimeout,
+		inflight:     make(chan struct{}, parallelism),
+	}, nil
+}
+
+type authRoundTripper struct {
+	auth Auth
+	next http.RoundTripper
+}
+
+func (rt authRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if rt.auth == (Auth{}) {
+		return rt.next.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+
+	switch {
+	case rt.auth.BearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+rt.auth.BearerToken)
+	case rt.auth.Username != "":
+		req.SetBasicAuth(rt.auth.Username, rt.auth.Password)
+	}
+
+	if rt.auth.Tenant != "" {
+		req.Header.Set(tenantHeader, rt.auth.Tenant)
+	}
+
+	return rt.next.RoundTrip(req)
+}
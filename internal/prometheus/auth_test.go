@@ -0,0 +1,65 @@
+package prometheus
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAuthAPIClient(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		auth       Auth
+		wantHeader http.Header
+	}{
+		{
+			name:       "no auth",
+			wantHeader: http.Header{},
+		},
+		{
+			name: "basic auth and tenant",
+			auth: Auth{Username: "user", Password: "secret", Tenant: "team-a"},
+			wantHeader: http.Header{
+				"Authorization": {"Basic dXNlcjpzZWNyZXQ="},
+				"X-Scope-Orgid": {"team-a"},
+			},
+		},
+		{
+			name:       "bearer token",
+			auth:       Auth{BearerToken: "token"},
+			wantHeader: http.Header{"Authorization": {"Bearer token"}},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var got http.Header
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = http.Header{}
+				for _, name := range []string{"Authorization", tenantHeader} {
+					if value := r.Header.Get(name); value != "" {
+						got.Set(name, value)
+					}
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`))
+			}))
+			defer server.Close()
+
+			client, err := NewAuthAPIClient(server.URL, 1, tt.auth)
+			require.NoError(t, err)
+
+			_, err = client.Query(t.Context(), "up", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantHeader, got)
+		})
+	}
+}
+
+func TestNewAuthAPIClient_basicAuthAndBearerToken(t *testing.T) {
+	_, err := NewAuthAPIClient("http://localhost:9090", 1, Auth{Username: "user", BearerToken: "token"})
+	assert.ErrorContains(t, err, "either basic auth or a bearer token")
+}
@@ -9,7 +9,6 @@ import (
 	"time"
 
 	"github.com/VictoriaMetrics/metricsql"
-	"github.com/prometheus/client_golang/api"
 	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
@@ -24,6 +23,7 @@ const (
 
 type Client interface {
 	LabelValues(context.Context, string, time.Time) ([]metricsql.LabelFilter, error)
+	Query(context.Context, string, time.Time) (promql.Vector, error)
 	QueryExpr(context.Context, string, time.Time, time.Time, time.Duration) (map[int64]promql.Vector, []time.Time, error)
 }
 
@@ -31,22 +31,21 @@ type APIClient struct {
 	api          v1.API
 	parallelism  int
 	queryTimeout time.Duration
+	// inflight holds a slot per running request, limiting all requests of the
+	// client to parallelism.
+	inflight chan struct{}
 }
 
 func NewAPIClient(prometheusURL string, parallelism int) (*APIClient, error) {
-	client, err := api.NewClient(api.Config{Address: prometheusURL})
-	if err != nil {
-		return nil, fmt.Errorf("creating Prometheus client: %w", err)
-	}
-
-	return &APIClient{
-		api:          v1.NewAPI(client),
-		parallelism:  parallelism,
-		queryTimeout: defaultQueryTimeout,
-	}, nil
+	return NewAuthAPIClient(prometheusURL, parallelism, Auth{})
 }
 
 func (a *APIClient) LabelValues(ctx context.Context, label string, ts time.Time) ([]metricsql.LabelFilter, error) {
+	if err := a.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer a.release()
+
 	ctx, cancel := context.WithTimeout(ctx, a.queryTimeout)
 	defer cancel()
 
@@ -86,6 +85,44 @@ func (a *APIClient) LabelValues(ctx context.Context, label string, ts time.Time)
 	return values, nil
 }
 
+// Query runs an instant query at ts. Scalar results are returned as a single
+// sample without labels, matching how rule expressions treat them.
+func (a *APIClient) Query(ctx context.Context, expr string, ts time.Time) (promql.Vector, error) {
+	if err := a.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer a.release()
+
+	ctx, cancel := context.WithTimeout(ctx, a.queryTimeout)
+	defer cancel()
+
+	result, warnings, err := a.api.Query(ctx, expr, ts)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, w := range warnings {
+		zlog.Warn().Str("warning", w).Msg("query warning")
+	}
+
+	switch v := result.(type) {
+	case model.Vector:
+		vector := make(promql.Vector, 0, len(v))
+		for _, sample := range v {
+			vector = append(vector, promql.Sample{
+				T:      sample.Timestamp.Time().UnixMilli(),
+				F:      float64(sample.Value),
+				Metric: metricToLabels(sample.Metric),
+			})
+		}
+		return vector, nil
+	case *model.Scalar:
+		return promql.Vector{{T: v.Timestamp.Time().UnixMilli(), F: float64(v.Value), Metric: labels.EmptyLabels()}}, nil
+	default:
+		return nil, fmt.Errorf("unexpected result type: %T", result)
+	}
+}
+
 func (a *APIClient) QueryExpr(
 	ctx context.Context,
 	expr string,
@@ -93,11 +130,10 @@ func (a *APIClient) QueryExpr(
 	to time.Time,
 	interval time.Duration,
 ) (map[int64]promql.Vector, []time.Time, error) {
-	from = alignToStep(from, interval)
-	to = alignToStep(to, interval)
+	to = AlignToStep(to, interval)
 
 	var (
-		timestamps = generateTimestamps(from, to, interval)
+		timestamps = Timestamps(from, to, interval)
 		vectors    = make(map[int64]promql.Vector, len(timestamps))
 		vectorsMu  sync.Mutex
 	)
@@ -160,6 +196,11 @@ func (a *APIClient) queryRange(
 	from, to time.Time,
 	interval time.Duration,
 ) (model.Matrix, error) {
+	if err := a.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer a.release()
+
 	ctx, cancel := context.WithTimeout(ctx, a.queryTimeout)
 	defer cancel()
 
@@ -184,15 +225,33 @@ func (a *APIClient) queryRange(
 	return matrix, nil
 }
 
+// acquire waits for a request slot. Requests issued concurrently by different
+// callers, such as replays of several targets and template queries, share
+// the slots.
+func (a *APIClient) acquire(ctx context.Context) error {
+	if a.inflight == nil {
+		return nil
+	}
+
+	select {
+	case a.inflight <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (a *APIClient) release() {
+	if a.inflight != nil {
+		<-a.inflight
+	}
+}
+
 func (a *APIClient) processMatrix(matrix model.Matrix, expectedTimestamp time.Time) []promql.Sample {
 	var samples []promql.Sample
 
 	for _, stream := range matrix {
-		lb := labels.NewBuilder(labels.EmptyLabels())
-		for k, v := range stream.Metric {
-			lb.Set(string(k), string(v))
-		}
-		metricLabels := lb.Labels()
+		metricLabels := metricToLabels(stream.Metric)
 
 		for _, sample := range stream.Values {
 			ts := sample.Timestamp.Time().UnixMilli()
@@ -213,6 +272,20 @@ func (a *APIClient) processMatrix(matrix model.Matrix, expectedTimestamp time.Ti
 	return samples
 }
 
+func metricToLabels(metric model.Metric) labels.Labels {
+	lb := labels.NewBuilder(labels.EmptyLabels())
+	for k, v := range metric {
+		lb.Set(string(k), string(v))
+	}
+
+	return lb.Labels()
+}
+
+// Timestamps returns the evaluation timestamps QueryExpr returns data for.
+func Timestamps(from time.Time, to time.Time, interval time.Duration) []time.Time {
+	return generateTimestamps(AlignToStep(from, interval), AlignToStep(to, interval), interval)
+}
+
 func generateTimestamps(from time.Time, to time.Time, interval time.Duration) []time.Time {
 	n := int((to.Sub(from) / interval) + 1)
 	timestamps := make([]time.Time, 0, n)
@@ -223,6 +296,7 @@ func generateTimestamps(from time.Time, to time.Time, interval time.Duration) []
 	return timestamps
 }
 
-func alignToStep(t time.Time, step time.Duration) time.Time {
+// AlignToStep rounds t down to the evaluation timestamp at or before it.
+func AlignToStep(t time.Time, step time.Duration) time.Time {
 	return time.UnixMilli((t.UnixMilli() / int64(step/time.Millisecond)) * int64(step/time.Millisecond)).UTC()
 }
@@ -2,12 +2,14 @@ package prometheus
 
 import (
 	"context"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/VictoriaMetrics/metricsql"
 	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/promql"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -83,7 +85,7 @@ func TestAlignToStep(t *testing.T) {
 		},
 	} {
 		t.Run(tt.name, func(t *testing.T) {
-			got := alignToStep(tt.t, tt.step)
+			got := AlignToStep(tt.t, tt.step)
 			assert.Equal(t, tt.want, got)
 		})
 	}
@@ -208,3 +210,98 @@ func TestLabelValues(t *testing.T) {
 		{Label: "cluster", Value: "b"},
 	}, got)
 }
+
+func TestQuery(t *testing.T) {
+	ts := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, tt := range []struct {
+		name    string
+		result  model.Value
+		want    promql.Vector
+		wantErr string
+	}{
+		{
+			name: "vector result",
+			result: model.Vector{
+				&model.Sample{Metric: model.Metric{"job": "api"}, Value: 3, Timestamp: model.TimeFromUnixNano(ts.UnixNano())},
+			},
+			want: promql.Vector{
+				{T: ts.UnixMilli(), F: 3, Metric: labels.FromStrings("job", "api")},
+			},
+		},
+		{
+			name:   "scalar result",
+			result: &model.Scalar{Value: 7, Timestamp: model.TimeFromUnixNano(ts.UnixNano())},
+			want: promql.Vector{
+				{T: ts.UnixMilli(), F: 7, Metric: labels.EmptyLabels()},
+			},
+		},
+		{
+			name:    "unexpected result type",
+			result:  model.Matrix{},
+			wantErr: "unexpected result type",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakePrometheusClient{result: tt.result}
+			client := &APIClient{
+				api:          api,
+				queryTimeout: time.Second,
+			}
+
+			got, err := client.Query(t.Context(), "up", ts)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, "up", api.query)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// blockingPrometheusClient counts the queries running at once.
+type blockingPrometheusClient struct {
+	promv1.API
+	mu      sync.Mutex
+	running int
+	max     int
+}
+
+func (b *blockingPrometheusClient) Query(
+	_ context.Context,
+	_ string,
+	_ time.Time,
+	_ ...promv1.Option,
+) (model.Value, promv1.Warnings, error) {
+	b.mu.Lock()
+	b.running++
+	b.max = max(b.max, b.running)
+	b.mu.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	b.mu.Lock()
+	b.running--
+	b.mu.Unlock()
+
+	return model.Vector{}, nil, nil
+}
+
+func TestQuery_parallelism(t *testing.T) {
+	api := &blockingPrometheusClient{}
+	client := &APIClient{api: api, queryTimeout: time.Second, inflight: make(chan struct{}, 2)}
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() {
+			_, err := client.Query(t.Context(), "up", time.Now())
+			assert.NoError(t, err)
+		})
+	}
+	wg.Wait()
+
+	assert.Equal(t, 2, api.max)
+}
@@ -0,0 +1,28 @@
+package prometheus
+
+import (
+	"time"
+
+	"github.com/prometheus/prometheus/promql"
+)
+
+const (
+	localQueryTimeout = 2 * time.Minute
+	localMaxSamples   = 50_000_000
+	// lookbackDelta is how far back an instant selector finds a sample, as in
+	// Prometheus.
+	lookbackDelta = 5 * time.Minute
+)
+
+// NewLocalEngine returns the engine evaluating expressions against a
+// MemoryStorage. Subqueries without a step use interval, as they would in a
+// rule group evaluated every interval.
+func NewLocalEngine(interval time.Duration) *promql.Engine {
+	return promql.NewEngine(promql.EngineOpts{
+		MaxSamples:               localMaxSamples,
+		Timeout:                  localQueryTimeout,
+		LookbackDelta:            lookbackDelta,
+		NoStepSubqueryIntervalFn: func(int64) int64 { return interval.Milliseconds() },
+		EnableNegativeOffset:     true,
+	})
+}
@@ -0,0 +1,150 @@
+package prometheus
+
+import (
+	"context"
+	"math"
+	"slices"
+
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/util/annotations"
+)
+
+// MemoryStorage holds series produced during a replay, such as ALERTS or the
+// output of recording rules, so the PromQL engine can query them. Samples must
+// be appended in increasing time order per series. It is not safe for
+// concurrent use.
+type MemoryStorage struct {
+	series map[string]*memSeries
+}
+
+type memSeries struct {
+	labels  labels.Labels
+	samples []chunks.Sample
+}
+
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{series: make(map[string]*memSeries)}
+}
+
+func (m *MemoryStorage) Append(lset labels.Labels, t int64, f float64) {
+	key := lset.String()
+
+	s, ok := m.series[key]
+	if !ok {
+		s = &memSeries{labels: lset}
+		m.series[key] = s
+	}
+
+	s.samples = append(s.samples, floatSample{t: t, f: f})
+}
+
+// Write appends the samples of vector at t and marks the series in previous
+// that vector doesn't have as stale, as Prometheus does after each rule
+// evaluation. It returns the series written, to pass as previous at the next
+// evaluation.
+func (m *MemoryStorage) Write(previous map[string]labels.Labels, t int64, vector promql.Vector) map[string]labels.Labels {
+	written := make(map[string]labels.Labels, len(vector))
+
+	for _, sample := range vector {
+		written[sample.Metric.String()] = sample.Metric
+		m.Append(sample.Metric, t, sample.F)
+	}
+
+	for key, lset := range previous {
+		if _, ok := written[key]; !ok {
+			m.Append(lset, t, math.Float64frombits(value.StaleNaN))
+		}
+	}
+
+	return written
+}
+
+func (m *MemoryStorage) Querier(mint, maxt int64) (storage.Querier, error) {
+	return &memQuerier{storage: m, mint: mint, maxt: maxt}, nil
+}
+
+type memQuerier struct {
+	storage    *MemoryStorage
+	mint, maxt int64
+}
+
+func (q *memQuerier) Select(_ context.Context, _ bool, _ *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+	var series []storage.Series
+
+	for _, s := range q.storage.series {
+		if !matchesAll(s.labels, matchers) {
+			continue
+		}
+
+		var samples []chunks.Sample
+		for _, sample := range s.samples {
+			if sample.T() >= q.mint && sample.T() <= q.maxt {
+				samples = append(samples, sample)
+			}
+		}
+
+		if len(samples) > 0 {
+			series = append(series, storage.NewListSeries(s.labels, samples))
+		}
+	}
+
+	slices.SortFunc(series, func(a, b storage.Series) int {
+		return labels.Compare(a.Labels(), b.Labels())
+	})
+
+	return &listSeriesSet{series: series, idx: -1}
+}
+
+func (*memQuerier) LabelValues(context.Context, string, *storage.LabelHints, ...*labels.Matcher) ([]string, annotations.Annotations, error) {
+	return nil, nil, nil
+}
+
+func (*memQuerier) LabelNames(context.Context, *storage.LabelHints, ...*labels.Matcher) ([]string, annotations.Annotations, error) {
+	return nil, nil, nil
+}
+
+func (*memQuerier) Close() error {
+	return nil
+}
+
+func matchesAll(lset labels.Labels, matchers []*labels.Matcher) bool {
+	for _, m := range matchers {
+		if !m.Matches(lset.Get(m.Name)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+type listSeriesSet struct {
+	series []storage.Series
+	idx    int
+}
+
+func (s *listSeriesSet) Next() bool {
+	s.idx++
+	return s.idx < len(s.series)
+}
+
+func (s *listSeriesSet) At() storage.Series              { return s.series[s.idx] }
+func (*listSeriesSet) Err() error                        { return nil }
+func (*listSeriesSet) Warnings() annotations.Annotations { return nil }
+
+type floatSample struct {
+	t int64
+	f float64
+}
+
+func (s floatSample) T() int64                    { return s.t }
+func (s floatSample) F() float64                  { return s.f }
+func (floatSample) H() *histogram.Histogram       { return nil }
+func (floatSample) FH() *histogram.FloatHistogram { return nil }
+func (floatSample) Type() chunkenc.ValueType      { return chunkenc.ValFloat }
+func (s floatSample) Copy() chunks.Sample         { return s }
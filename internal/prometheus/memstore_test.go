@@ -0,0 +1,95 @@
+package prometheus
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/rules"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStorage(t *testing.T) {
+	var (
+		base  = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		step  = 30 * time.Second
+		store = NewMemoryStorage()
+		api   = labels.FromStrings("__name__", "ALERTS", "alertname", "Down", "alertstate", "firing", "job", "api")
+		db    = labels.FromStrings("__name__", "ALERTS", "alertname", "Down", "alertstate", "pending", "job", "db")
+	)
+
+	store.Append(api, base.UnixMilli(), 1)
+	store.Append(db, base.UnixMilli(), 1)
+	store.Append(api, base.Add(step).UnixMilli(), 1)
+	store.Append(db, base.Add(step).UnixMilli(), math.Float64frombits(value.StaleNaN))
+
+	queryFn := rules.EngineQueryFunc(promql.NewEngine(promql.EngineOpts{MaxSamples: 1000, Timeout: time.Minute}), store)
+
+	for _, tt := range []struct {
+		name     string
+		query    string
+		ts       time.Time
+		expected []float64
+	}{
+		{
+			name:     "matchers select series",
+			query:    `count(ALERTS{alertstate="firing"})`,
+			ts:       base,
+			expected: []float64{1},
+		},
+		{
+			name:     "all series",
+			query:    `count(ALERTS)`,
+			ts:       base,
+			expected: []float64{2},
+		},
+		{
+			name:     "stale series are dropped",
+			query:    `count(ALERTS)`,
+			ts:       base.Add(step),
+			expected: []float64{1},
+		},
+		{
+			name:  "no matching series",
+			query: `ALERTS{job="web"}`,
+			ts:    base,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			vector, err := queryFn(t.Context(), tt.query, tt.ts)
+			require.NoError(t, err)
+
+			var got []float64
+			for _, sample := range vector {
+				got = append(got, sample.F)
+			}
+
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestMemoryStorageWrite(t *testing.T) {
+	var (
+		base  = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		step  = 30 * time.Second
+		store = NewMemoryStorage()
+		api   = labels.FromStrings("__name__", "up", "job", "api")
+		db    = labels.FromStrings("__name__", "up", "job", "db")
+	)
+
+	written := store.Write(nil, base.UnixMilli(), promql.Vector{{Metric: api, F: 1}, {Metric: db, F: 2}})
+	written = store.Write(written, base.Add(step).UnixMilli(), promql.Vector{{Metric: api, F: 3}})
+	assert.Equal(t, map[string]labels.Labels{api.String(): api}, written)
+
+	assert.Equal(t, []chunks.Sample{floatSample{base.UnixMilli(), 1}, floatSample{base.Add(step).UnixMilli(), 3}}, store.series[api.String()].samples)
+
+	dbSamples := store.series[db.String()].samples
+	require.Len(t, dbSamples, 2)
+	assert.True(t, value.IsStaleNaN(dbSamples[1].F()), "series no longer written are marked stale")
+}
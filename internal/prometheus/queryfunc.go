@@ -2,6 +2,7 @@ package prometheus
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/prometheus/prometheus/promql"
@@ -13,3 +14,47 @@ func CachedQueryFunc(cache map[int64]promql.Vector) rules.QueryFunc {
 		return cache[t.UnixMilli()], nil
 	}
 }
+
+// ExprQueryFunc serves expr from cache and runs any other query, such as
+// those issued by the `query` template function, against client at the
+// evaluation time. Prometheus expands the annotations of every active alert
+// at every evaluation, so the results of other queries are memoised by query
+// and time.
+func ExprQueryFunc(cache map[int64]promql.Vector, expr string, client Client) rules.QueryFunc {
+	type key struct {
+		query string
+		t     int64
+	}
+
+	var (
+		cached = CachedQueryFunc(cache)
+		mu     sync.Mutex
+		memo   = make(map[key]promql.Vector)
+	)
+
+	return func(ctx context.Context, qs string, t time.Time) (promql.Vector, error) {
+		if qs == expr {
+			return cached(ctx, qs, t)
+		}
+
+		k := key{query: qs, t: t.UnixMilli()}
+
+		mu.Lock()
+		vector, ok := memo[k]
+		mu.Unlock()
+		if ok {
+			return vector, nil
+		}
+
+		vector, err := client.Query(ctx, qs, t)
+		if err != nil {
+			return nil, err
+		}
+
+		mu.Lock()
+		memo[k] = vector
+		mu.Unlock()
+
+		return vector, nil
+	}
+}
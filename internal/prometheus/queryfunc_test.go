@@ -31,3 +31,51 @@ func TestCachedQueryFunc(t *testing.T) {
 		assert.Nil(t, got)
 	})
 }
+
+type fakeClient struct {
+	Client
+	queries []string
+	result  promql.Vector
+}
+
+func (f *fakeClient) Query(_ context.Context, expr string, _ time.Time) (promql.Vector, error) {
+	f.queries = append(f.queries, expr)
+	return f.result, nil
+}
+
+func TestExprQueryFunc(t *testing.T) {
+	ts := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	cached := promql.Sample{T: ts.UnixMilli(), F: 1.0}
+	live := promql.Sample{T: ts.UnixMilli(), F: 2.0}
+
+	client := &fakeClient{result: promql.Vector{live}}
+	qf := ExprQueryFunc(map[int64]promql.Vector{ts.UnixMilli(): {cached}}, "up == 0", client)
+
+	t.Run("serves the rule expression from cache", func(t *testing.T) {
+		got, err := qf(context.Background(), "up == 0", ts)
+		require.NoError(t, err)
+		assert.Equal(t, promql.Vector{cached}, got)
+		assert.Empty(t, client.queries)
+	})
+
+	t.Run("queries the client for other expressions", func(t *testing.T) {
+		got, err := qf(context.Background(), `node_uname_info{instance="a"}`, ts)
+		require.NoError(t, err)
+		assert.Equal(t, promql.Vector{live}, got)
+		assert.Equal(t, []string{`node_uname_info{instance="a"}`}, client.queries)
+	})
+
+	t.Run("memoises other expressions by time", func(t *testing.T) {
+		client.queries = nil
+
+		for range 3 {
+			got, err := qf(context.Background(), `node_uname_info{instance="b"}`, ts)
+			require.NoError(t, err)
+			assert.Equal(t, promql.Vector{live}, got)
+		}
+		_, err := qf(context.Background(), `node_uname_info{instance="b"}`, ts.Add(time.Minute))
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{`node_uname_info{instance="b"}`, `node_uname_info{instance="b"}`}, client.queries)
+	})
+}
@@ -0,0 +1,544 @@
+package prometheus
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/metricsql"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	zlog "github.com/rs/zerolog/log"
+	"golang.org/x/sync/errgroup"
+)
+
+// remoteLabel marks the series holding the result of a sub-expression queried
+// from Prometheus while evaluating an expression locally.
+const remoteLabel = "__alertreplay_remote__"
+
+// recordingClient evaluates expressions that read series of recording rules
+// locally. The recording rules are evaluated over the replayed range and
+// their results written to a MemoryStorage, together with the parts of the
+// expression that don't read recorded series, which are queried from the
+// wrapped client. The expression then runs on that storage, so recorded
+// series keep their labels and name as Prometheus would have recorded them.
+type recordingClient struct {
+	Client
+	recordings map[string][]rulefmt.Rule
+	from, to   time.Time
+	interval   time.Duration
+	engine     *promql.Engine
+	// filters select the series recording rules are evaluated over.
+	filters []metricsql.LabelFilter
+	// instant holds the storage instant queries within the replayed range are
+	// evaluated against, loaded once per expression.
+	instant *instantStores
+}
+
+type instantStores struct {
+	mu     sync.Mutex
+	stores map[string]*instantStore
+}
+
+type instantStore struct {
+	once  sync.Once
+	store *MemoryStorage
+	err   error
+}
+
+// WithRecordingRules returns a client that evaluates the series recorded by
+// recordings instead of querying them from client, as they may not exist for
+// the whole range replayed from from to to. Recording rules are evaluated
+// every interval, and subqueries without a step use it. Instant queries
+// within the range, such as those of annotation templates, reuse the
+// recorded series of the whole range.
+func WithRecordingRules(client Client, recordings []rulefmt.Rule, from, to time.Time, interval time.Duration) Client {
+	if len(recordings) == 0 {
+		return client
+	}
+
+	c := &recordingClient{
+		Client:     client,
+		recordings: make(map[string][]rulefmt.Rule),
+		from:       from,
+		to:         to,
+		interval:   interval,
+		engine:     NewLocalEngine(interval),
+		instant:    &instantStores{stores: make(map[string]*instantStore)},
+	}
+
+	for _, r := range recordings {
+		c.recordings[r.Record] = append(c.recordings[r.Record], r)
+	}
+
+	return c
+}
+
+// WithFilters returns client for replaying a target selected by filters, for
+// expressions already rewritten with RewriteExpr. Recording rules are
+// evaluated over the series matching filters, and the filters are dropped
+// from selectors of recorded series, which may not keep the filtered labels.
+// Clients without recording rules are returned as is.
+func WithFilters(client Client, filters ...metricsql.LabelFilter) Client {
+	c, ok := client.(*recordingClient)
+	if !ok {
+		return client
+	}
+
+	filtered := *c
+	filtered.filters = nil
+	filtered.instant = &instantStores{stores: make(map[string]*instantStore)}
+	for _, f := range filters {
+		if f.Label != "" {
+			filtered.filters = append(filtered.filters, f)
+		}
+	}
+
+	return &filtered
+}
+
+func (c *recordingClient) Query(ctx context.Context, expr string, ts time.Time) (promql.Vector, error) {
+	p, err := c.plan(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	if p == nil {
+		return c.Client.Query(ctx, expr, ts)
+	}
+
+	store, err := c.instantStore(ctx, expr, p, ts)
+	if err != nil {
+		return nil, err
+	}
+
+	q, err := c.engine.NewInstantQuery(ctx, store, nil, p.expr, ts)
+	if err != nil {
+		return nil, fmt.Errorf("evaluating %q: %w", expr, err)
+	}
+	defer q.Close()
+
+	res := q.Exec(ctx)
+	if res.Err != nil {
+		return nil, fmt.Errorf("evaluating %q: %w", expr, res.Err)
+	}
+
+	switch v := res.Value.(type) {
+	case promql.Vector:
+		return v, nil
+	case promql.Scalar:
+		return promql.Vector{{T: v.T, F: v.V, Metric: labels.EmptyLabels()}}, nil
+	default:
+		return nil, fmt.Errorf("unexpected result type: %T", res.Value)
+	}
+}
+
+// instantStore returns the storage to evaluate p, planned for expr, at ts. For
+// ts within the replayed range it is loaded once over the whole range and
+// shared by all instant queries of expr.
+func (c *recordingClient) instantStore(ctx context.Context, expr string, p *localPlan, ts time.Time) (*MemoryStorage, error) {
+	if ts.Before(c.from) || ts.After(c.to) {
+		store := NewMemoryStorage()
+		if err := c.load(ctx, p, store, ts, ts, c.interval, make(map[string]bool)); err != nil {
+			return nil, err
+		}
+
+		return store, nil
+	}
+
+	c.instant.mu.Lock()
+	s, ok := c.instant.stores[expr]
+	if !ok {
+		s = &instantStore{}
+		c.instant.stores[expr] = s
+	}
+	c.instant.mu.Unlock()
+
+	s.once.Do(func() {
+		timestamps := Timestamps(c.from, c.to, c.interval)
+
+		s.store = NewMemoryStorage()
+		s.err = c.load(ctx, p, s.store, timestamps[0], timestamps[len(timestamps)-1], c.interval, make(map[string]bool))
+	})
+
+	return s.store, s.err
+}
+
+func (c *recordingClient) QueryExpr(
+	ctx context.Context,
+	expr string,
+	from time.Time,
+	to time.Time,
+	interval time.Duration,
+) (map[int64]promql.Vector, []time.Time, error) {
+	return c.queryExpr(ctx, expr, from, to, interval, make(map[string]bool))
+}
+
+// queryExpr is QueryExpr for an expression read while evaluating the
+// recording rules in visiting.
+func (c *recordingClient) queryExpr(
+	ctx context.Context,
+	expr string,
+	from time.Time,
+	to time.Time,
+	interval time.Duration,
+	visiting map[string]bool,
+) (map[int64]promql.Vector, []time.Time, error) {
+	p, err := c.plan(expr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if p == nil {
+		return c.Client.QueryExpr(ctx, expr, from, to, interval)
+	}
+
+	timestamps := Timestamps(from, to, interval)
+
+	store := NewMemoryStorage()
+	if err := c.load(ctx, p, store, timestamps[0], timestamps[len(timestamps)-1], interval, visiting); err != nil {
+		return nil, nil, err
+	}
+
+	q, err := c.engine.NewRangeQuery(ctx, store, nil, p.expr, timestamps[0], timestamps[len(timestamps)-1], interval)
+	if err != nil {
+		return nil, nil, fmt.Errorf("evaluating %q: %w", expr, err)
+	}
+	defer q.Close()
+
+	res := q.Exec(ctx)
+	if res.Err != nil {
+		return nil, nil, fmt.Errorf("evaluating %q: %w", expr, res.Err)
+	}
+
+	matrix, ok := res.Value.(promql.Matrix)
+	if !ok {
+		return nil, nil, fmt.Errorf("unexpected result type: %T", res.Value)
+	}
+
+	vectors := make(map[int64]promql.Vector, len(timestamps))
+	for _, series := range matrix {
+		for _, p := range series.Floats {
+			vectors[p.T] = append(vectors[p.T], promql.Sample{T: p.T, F: p.F, Metric: series.Metric})
+		}
+		for _, p := range series.Histograms {
+			vectors[p.T] = append(vectors[p.T], promql.Sample{T: p.T, H: p.H, Metric: series.Metric})
+		}
+	}
+
+	return vectors, timestamps, nil
+}
+
+// load writes the recorded series and remote sub-expressions read by p to
+// store, from as far before from as p reads up to to.
+func (c *recordingClient) load(
+	ctx context.Context,
+	p *localPlan,
+	store *MemoryStorage,
+	from time.Time,
+	to time.Time,
+	interval time.Duration,
+	visiting map[string]bool,
+) error {
+	type result struct {
+		vectors    map[int64]promql.Vector
+		timestamps []time.Time
+		relabel    func(labels.Labels) labels.Labels
+	}
+
+	var (
+		eg      errgroup.Group
+		records = slices.Sorted(maps.Keys(p.records))
+		results []*result
+	)
+
+	for _, name := range records {
+		if visiting[name] {
+			return fmt.Errorf("recording rule %q depends on itself", name)
+		}
+
+		zlog.Debug().Str("record", name).Msg("evaluating recording rule")
+
+		nested := maps.Clone(visiting)
+		nested[name] = true
+
+		for _, rec := range c.recordings[name] {
+			res := &result{relabel: recordedLabels(rec)}
+			results = append(results, res)
+
+			eg.Go(func() error {
+				expr, err := RewriteExpr(rec.Expr, c.filters...)
+				if err != nil {
+					return fmt.Errorf("recording rule %q: %w", name, err)
+				}
+
+				vectors, timestamps, err := c.queryExpr(ctx, expr, from.Add(-p.records[name]), to, interval, nested)
+				if err != nil {
+					return fmt.Errorf("recording rule %q: %w", name, err)
+				}
+
+				res.vectors, res.timestamps = vectors, timestamps
+
+				return nil
+			})
+		}
+	}
+
+	for i, r := range p.remote {
+		res := &result{relabel: func(lset labels.Labels) labels.Labels {
+			return labels.NewBuilder(lset).Set(remoteLabel, strconv.Itoa(i)).Labels()
+		}}
+		results = append(results, res)
+
+		eg.Go(func() error {
+			vectors, timestamps, err := c.Client.QueryExpr(ctx, r.expr, from.Add(-r.history), to, interval)
+			if err != nil {
+				return err
+			}
+
+			res.vectors, res.timestamps = vectors, timestamps
+
+			return nil
+		})
+	}
+
+	if err := eg.Wait(); err != nil {
+		return err
+	}
+
+	for _, res := range results {
+		writeSeries(store, res.vectors, res.timestamps, res.relabel)
+	}
+
+	return nil
+}
+
+// recordedLabels returns the labels rec records a sample of its expression
+// with.
+func recordedLabels(rec rulefmt.Rule) func(labels.Labels) labels.Labels {
+	return func(lset labels.Labels) labels.Labels {
+		b := labels.NewBuilder(lset)
+		b.Set(labels.MetricName, rec.Record)
+		for name, value := range rec.Labels {
+			b.Set(name, value)
+		}
+
+		return b.Labels()
+	}
+}
+
+// writeSeries appends vectors to store at timestamps, relabeled, as a rule
+// evaluated at each of them would.
+func writeSeries(store *MemoryStorage, vectors map[int64]promql.Vector, timestamps []time.Time, relabel func(labels.Labels) labels.Labels) {
+	var written map[string]labels.Labels
+
+	for _, ts := range timestamps {
+		vector := make(promql.Vector, 0, len(vectors[ts.UnixMilli()]))
+		for _, sample := range vectors[ts.UnixMilli()] {
+			sample.Metric = relabel(sample.Metric)
+			vector = append(vector, sample)
+		}
+
+		written = store.Write(written, ts.UnixMilli(), vector)
+	}
+}
+
+// localPlan is an expression reading recorded series, rewritten to be
+// evaluated against a MemoryStorage.
+type localPlan struct {
+	expr string
+	// records is how far back each recorded series is read.
+	records map[string]time.Duration
+	// remote are the parts of the expression that don't read recorded series.
+	// The series of the i-th are stored with remoteLabel set to i.
+	remote []remoteExpr
+}
+
+type remoteExpr struct {
+	expr string
+	// history is how far back the result is read.
+	history time.Duration
+}
+
+// plan returns how to evaluate expr locally, or nil if it doesn't read
+// recorded series.
+func (c *recordingClient) plan(expr string) (*localPlan, error) {
+	parsed, err := parser.ParseExpr(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	if !c.readsRecorded(parsed) {
+		return nil, nil
+	}
+
+	p := &localPlan{records: make(map[string]time.Duration)}
+
+	rewritten, err := c.rewrite(p, parsed, 0)
+	if err != nil {
+		return nil, err
+	}
+	p.expr = rewritten.String()
+
+	return p, nil
+}
+
+// rewrite replaces the parts of node that don't read recorded series with
+// selectors of their remote results, and records in p how far back before
+// the evaluation time history each series is read.
+func (c *recordingClient) rewrite(p *localPlan, node parser.Expr, history time.Duration) (parser.Expr, error) {
+	if !c.readsRecorded(node) {
+		return p.remoteExpr(node, history)
+	}
+
+	var err error
+
+	switch n := node.(type) {
+	case *parser.VectorSelector:
+		err = c.read(p, n, history)
+	case *parser.MatrixSelector:
+		err = c.read(p, n.VectorSelector.(*parser.VectorSelector), history+n.Range)
+	case *parser.SubqueryExpr:
+		if n.Timestamp != nil || n.StartOrEnd != 0 {
+			return nil, fmt.Errorf("@ modifier on subqueries of recorded series is not supported")
+		}
+		n.Expr, err = c.rewrite(p, n.Expr, history+n.Range+max(n.OriginalOffset, 0))
+	case *parser.BinaryExpr:
+		if n.LHS, err = c.rewrite(p, n.LHS, history); err == nil {
+			n.RHS, err = c.rewrite(p, n.RHS, history)
+		}
+	case *parser.Call:
+		for i, arg := range n.Args {
+			if n.Args[i], err = c.rewrite(p, arg, history); err != nil {
+				break
+			}
+		}
+	case *parser.AggregateExpr:
+		if n.Param != nil {
+			if n.Param, err = c.rewrite(p, n.Param, history); err != nil {
+				break
+			}
+		}
+		n.Expr, err = c.rewrite(p, n.Expr, history)
+	case *parser.ParenExpr:
+		n.Expr, err = c.rewrite(p, n.Expr, history)
+	case *parser.UnaryExpr:
+		n.Expr, err = c.rewrite(p, n.Expr, history)
+	case *parser.StepInvariantExpr:
+		n.Expr, err = c.rewrite(p, n.Expr, history)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return node, nil
+}
+
+// read records that the recorded series selected by vs are read history
+// before the evaluation time, and drops the filters of c from vs.
+func (c *recordingClient) read(p *localPlan, vs *parser.VectorSelector, history time.Duration) error {
+	if vs.Timestamp != nil || vs.StartOrEnd != 0 {
+		return fmt.Errorf("@ modifier on recorded series %q is not supported", vs.Name)
+	}
+
+	vs.LabelMatchers = slices.DeleteFunc(vs.LabelMatchers, func(m *labels.Matcher) bool {
+		return slices.ContainsFunc(c.filters, func(f metricsql.LabelFilter) bool { return isFilter(m, f) })
+	})
+
+	name := selectorName(vs)
+	p.records[name] = max(p.records[name], history+max(vs.OriginalOffset, 0)+lookbackDelta)
+
+	return nil
+}
+
+// isFilter reports whether m is the matcher of f.
+func isFilter(m *labels.Matcher, f metricsql.LabelFilter) bool {
+	matchType := labels.MatchEqual
+	switch {
+	case f.IsRegexp && f.IsNegative:
+		matchType = labels.MatchNotRegexp
+	case f.IsRegexp:
+		matchType = labels.MatchRegexp
+	case f.IsNegative:
+		matchType = labels.MatchNotEqual
+	}
+
+	return m.Type == matchType && m.Name == f.Label && m.Value == f.Value
+}
+
+// remoteExpr returns the selector of the result of node queried from
+// Prometheus. Nodes that don't select any series, such as numbers, are kept.
+func (p *localPlan) remoteExpr(node parser.Expr, history time.Duration) (parser.Expr, error) {
+	if !selectsSeries(node) {
+		return node, nil
+	}
+
+	selector := fmt.Sprintf(`{%s="%d"}`, remoteLabel, len(p.remote))
+
+	var expr string
+	switch node.Type() {
+	case parser.ValueTypeVector:
+		expr = fmt.Sprintf(`label_replace(%s, %q, "", "", "")`, selector, remoteLabel)
+	case parser.ValueTypeScalar:
+		expr = fmt.Sprintf(`scalar(%s)`, selector)
+	default:
+		return nil, fmt.Errorf("%s can't be evaluated together with recorded series", node)
+	}
+
+	p.remote = append(p.remote, remoteExpr{expr: node.String(), history: history + lookbackDelta})
+
+	return parser.ParseExpr(expr)
+}
+
+// readsRecorded reports whether node selects series of a recording rule.
+func (c *recordingClient) readsRecorded(node parser.Node) bool {
+	var found bool
+
+	parser.Inspect(node, func(n parser.Node, _ []parser.Node) error {
+		if vs, ok := n.(*parser.VectorSelector); ok {
+			if _, ok := c.recordings[selectorName(vs)]; ok {
+				found = true
+			}
+		}
+
+		return nil
+	})
+
+	return found
+}
+
+func selectsSeries(node parser.Node) bool {
+	var found bool
+
+	parser.Inspect(node, func(n parser.Node, _ []parser.Node) error {
+		if _, ok := n.(*parser.VectorSelector); ok {
+			found = true
+		}
+
+		return nil
+	})
+
+	return found
+}
+
+// selectorName returns the metric name vs selects by equality, if any.
+func selectorName(vs *parser.VectorSelector) string {
+	if vs.Name != "" {
+		return vs.Name
+	}
+
+	for _, m := range vs.LabelMatchers {
+		if m.Name == labels.MetricName && m.Type == labels.MatchEqual {
+			return m.Value
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,280 @@
+package prometheus
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/metricsql"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// storageClient answers queries from raw series in a MemoryStorage, as
+// Prometheus would, and records the queries it receives.
+type storageClient struct {
+	Client
+	store  *MemoryStorage
+	engine *promql.Engine
+
+	mu      sync.Mutex
+	queries []string
+	froms   map[string]time.Time
+}
+
+func newStorageClient(store *MemoryStorage) *storageClient {
+	return &storageClient{
+		store:  store,
+		engine: NewLocalEngine(time.Minute),
+		froms:  make(map[string]time.Time),
+	}
+}
+
+func (s *storageClient) Query(ctx context.Context, expr string, ts time.Time) (promql.Vector, error) {
+	s.mu.Lock()
+	s.queries = append(s.queries, expr)
+	s.mu.Unlock()
+
+	q, err := s.engine.NewInstantQuery(ctx, s.store, nil, expr, ts)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+
+	res := q.Exec(ctx)
+	if res.Err != nil {
+		return nil, res.Err
+	}
+
+	return res.Vector()
+}
+
+func (s *storageClient) QueryExpr(ctx context.Context, expr string, from, to time.Time, interval time.Duration) (map[int64]promql.Vector, []time.Time, error) {
+	s.mu.Lock()
+	s.queries = append(s.queries, expr)
+	s.froms[expr] = from
+	s.mu.Unlock()
+
+	timestamps := Timestamps(from, to, interval)
+
+	q, err := s.engine.NewRangeQuery(ctx, s.store, nil, expr, timestamps[0], timestamps[len(timestamps)-1], interval)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer q.Close()
+
+	res := q.Exec(ctx)
+	if res.Err != nil {
+		return nil, nil, res.Err
+	}
+
+	matrix, err := res.Matrix()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	vectors := make(map[int64]promql.Vector)
+	for _, series := range matrix {
+		for _, p := range series.Floats {
+			vectors[p.T] = append(vectors[p.T], promql.Sample{T: p.T, F: p.F, Metric: series.Metric})
+		}
+	}
+
+	return vectors, timestamps, nil
+}
+
+func TestWithRecordingRules(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// errors_total grows by 1/s for instance a and 2/s for instance b of the
+	// api job, and requests_total by 100/s.
+	store := NewMemoryStorage()
+	for ts := base.Add(-2 * time.Hour); !ts.After(base.Add(time.Hour)); ts = ts.Add(15 * time.Second) {
+		elapsed := ts.Sub(base.Add(-2 * time.Hour)).Seconds()
+		store.Append(labels.FromStrings("__name__", "errors_total", "job", "api", "instance", "a"), ts.UnixMilli(), elapsed)
+		store.Append(labels.FromStrings("__name__", "errors_total", "job", "api", "instance", "b"), ts.UnixMilli(), 2*elapsed)
+		store.Append(labels.FromStrings("__name__", "requests_total", "job", "api"), ts.UnixMilli(), 100*elapsed)
+		store.Append(labels.FromStrings("__name__", "up", "cluster", "c1", "region", "eu"), ts.UnixMilli(), 1)
+	}
+
+	recordings := []rulefmt.Rule{
+		{Record: "job:errors:rate5m", Expr: `sum by (job) (rate(errors_total[5m]))`},
+		{Record: "job:errors:ratio5m", Expr: `job:errors:rate5m / on (job) sum by (job) (rate(requests_total[5m]))`},
+		{Record: "cluster:up:count", Expr: `count by (cluster) (up{region="us"})`, Labels: map[string]string{"region": "us"}},
+		{Record: "cluster:up:count", Expr: `count by (cluster) (up{region="eu"})`, Labels: map[string]string{"region": "eu"}},
+	}
+
+	for _, tt := range []struct {
+		name       string
+		expr       string
+		want       promql.Vector
+		wantRemote []string
+	}{
+		{
+			name:       "expressions without recorded series are queried as is",
+			expr:       `sum by (job) (rate(errors_total[5m])) > 1`,
+			want:       promql.Vector{{F: 3, Metric: labels.FromStrings("job", "api")}},
+			wantRemote: []string{`sum by (job) (rate(errors_total[5m])) > 1`},
+		},
+		{
+			name:       "recorded series keep their name",
+			expr:       `job:errors:rate5m > 1`,
+			want:       promql.Vector{{F: 3, Metric: labels.FromStrings("__name__", "job:errors:rate5m", "job", "api")}},
+			wantRemote: []string{`sum by (job) (rate(errors_total[5m]))`},
+		},
+		{
+			name:       "filters on labels aggregated away by the recording rule match nothing",
+			expr:       `job:errors:rate5m{instance="a"}`,
+			want:       nil,
+			wantRemote: []string{`sum by (job) (rate(errors_total[5m]))`},
+		},
+		{
+			name:       "parts without recorded series are queried remotely",
+			expr:       `job:errors:rate5m / on (job) sum by (job) (rate(requests_total[5m])) > 0.01`,
+			want:       promql.Vector{{F: 0.03, Metric: labels.FromStrings("job", "api")}},
+			wantRemote: []string{`sum by (job) (rate(errors_total[5m]))`, `sum by (job) (rate(requests_total[5m]))`},
+		},
+		{
+			name:       "transitive dependencies",
+			expr:       `job:errors:ratio5m > 0.01`,
+			want:       promql.Vector{{F: 0.03, Metric: labels.FromStrings("__name__", "job:errors:ratio5m", "job", "api")}},
+			wantRemote: []string{`sum by (job) (rate(errors_total[5m]))`, `sum by (job) (rate(requests_total[5m]))`},
+		},
+		{
+			name:       "range selectors read recorded history",
+			expr:       `max_over_time(job:errors:rate5m[1h])`,
+			want:       promql.Vector{{F: 3, Metric: labels.FromStrings("job", "api")}},
+			wantRemote: []string{`sum by (job) (rate(errors_total[5m]))`},
+		},
+		{
+			name:       "recording rules with static labels",
+			expr:       `cluster:up:count{region="eu"}`,
+			want:       promql.Vector{{F: 1, Metric: labels.FromStrings("__name__", "cluster:up:count", "cluster", "c1", "region", "eu")}},
+			wantRemote: []string{`count by (cluster) (up{region="us"})`, `count by (cluster) (up{region="eu"})`},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			remote := newStorageClient(store)
+			client := WithRecordingRules(remote, recordings, base, base.Add(time.Hour), time.Minute)
+
+			vectors, timestamps, err := client.QueryExpr(t.Context(), tt.expr, base, base.Add(10*time.Minute), time.Minute)
+			require.NoError(t, err)
+			assert.Equal(t, Timestamps(base, base.Add(10*time.Minute), time.Minute), timestamps)
+			assert.ElementsMatch(t, tt.wantRemote, remote.queries)
+
+			for _, ts := range timestamps {
+				got := vectors[ts.UnixMilli()]
+				require.Len(t, got, len(tt.want), "at %s", ts)
+
+				for i, sample := range got {
+					assert.Equal(t, tt.want[i].Metric, sample.Metric)
+					assert.InDelta(t, tt.want[i].F, sample.F, 1e-9)
+				}
+			}
+
+			vector, err := client.Query(t.Context(), tt.expr, base)
+			require.NoError(t, err)
+			require.Len(t, vector, len(tt.want))
+			for i, sample := range vector {
+				assert.Equal(t, tt.want[i].Metric, sample.Metric)
+				assert.InDelta(t, tt.want[i].F, sample.F, 1e-9)
+			}
+		})
+	}
+}
+
+func TestWithRecordingRules_history(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	remote := newStorageClient(NewMemoryStorage())
+	client := WithRecordingRules(remote, []rulefmt.Rule{{Record: "a", Expr: `up`}}, base, base.Add(time.Hour), time.Minute)
+
+	_, _, err := client.QueryExpr(t.Context(), `max_over_time(a[1h] offset 30m) + on () group_left sum(b)`, base, base.Add(time.Hour), time.Minute)
+	require.NoError(t, err)
+
+	assert.Equal(t, base.Add(-90*time.Minute-lookbackDelta), remote.froms[`up`])
+	assert.Equal(t, base.Add(-lookbackDelta), remote.froms[`sum(b)`])
+}
+
+func TestWithRecordingRules_errors(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	remote := newStorageClient(NewMemoryStorage())
+	client := WithRecordingRules(remote, []rulefmt.Rule{
+		{Record: "a", Expr: `b + 1`},
+		{Record: "b", Expr: `a + 1`},
+		{Record: "c", Expr: `up`},
+	}, base, base.Add(time.Hour), time.Minute)
+
+	_, _, err := client.QueryExpr(t.Context(), `a > 0`, base, base.Add(time.Hour), time.Minute)
+	assert.ErrorContains(t, err, `recording rule "a" depends on itself`)
+
+	_, _, err = client.QueryExpr(t.Context(), `c @ 100`, base, base.Add(time.Hour), time.Minute)
+	assert.ErrorContains(t, err, "@ modifier")
+
+	_, _, err = client.QueryExpr(t.Context(), `predict_linear(up[1h], scalar(c))`, base, base.Add(time.Hour), time.Minute)
+	assert.ErrorContains(t, err, "can't be evaluated together with recorded series")
+}
+
+func TestWithRecordingRules_none(t *testing.T) {
+	remote := newStorageClient(NewMemoryStorage())
+	assert.Same(t, remote, WithRecordingRules(remote, nil, time.Time{}, time.Time{}, time.Minute))
+}
+
+func TestWithRecordingRules_instant(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	store := NewMemoryStorage()
+	for ts := base.Add(-time.Hour); !ts.After(base.Add(2 * time.Hour)); ts = ts.Add(15 * time.Second) {
+		store.Append(labels.FromStrings("__name__", "up", "job", "api"), ts.UnixMilli(), 1)
+	}
+
+	remote := newStorageClient(store)
+	client := WithRecordingRules(remote, []rulefmt.Rule{{Record: "job:up:count", Expr: `count by (job) (up)`}}, base, base.Add(time.Hour), time.Minute)
+
+	for _, ts := range []time.Time{base, base.Add(10 * time.Minute), base.Add(time.Hour)} {
+		vector, err := client.Query(t.Context(), `job:up:count`, ts)
+		require.NoError(t, err)
+		require.Len(t, vector, 1)
+		assert.Equal(t, 1.0, vector[0].F)
+	}
+
+	assert.Equal(t, []string{`count by (job) (up)`}, remote.queries, "instant queries within the range share the recorded series")
+
+	_, err := client.Query(t.Context(), `job:up:count`, base.Add(90*time.Minute))
+	require.NoError(t, err)
+	assert.Len(t, remote.queries, 2, "instant queries outside the range evaluate the recording rules at their own time")
+}
+
+func TestWithFilters(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	store := NewMemoryStorage()
+	for ts := base.Add(-time.Hour); !ts.After(base.Add(time.Hour)); ts = ts.Add(15 * time.Second) {
+		store.Append(labels.FromStrings("__name__", "up", "job", "api", "cluster", "a"), ts.UnixMilli(), 1)
+		store.Append(labels.FromStrings("__name__", "up", "job", "api", "cluster", "b"), ts.UnixMilli(), 1)
+	}
+
+	remote := newStorageClient(store)
+	client := WithFilters(
+		WithRecordingRules(remote, []rulefmt.Rule{{Record: "job:up:count", Expr: `count by (job) (up)`}}, base, base.Add(time.Hour), time.Minute),
+		metricsql.LabelFilter{Label: "cluster", Value: "a"},
+	)
+
+	// The target filter is added to every selector, including the recorded
+	// one, which aggregated the cluster label away.
+	expr, err := RewriteExpr(`job:up:count > 0`, metricsql.LabelFilter{Label: "cluster", Value: "a"})
+	require.NoError(t, err)
+
+	vectors, _, err := client.QueryExpr(t.Context(), expr, base, base, time.Minute)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{`count(up{cluster="a"}) by(job)`}, remote.queries)
+	require.Len(t, vectors[base.UnixMilli()], 1)
+	assert.Equal(t, labels.FromStrings("__name__", "job:up:count", "job", "api"), vectors[base.UnixMilli()][0].Metric)
+	assert.Equal(t, 1.0, vectors[base.UnixMilli()][0].F, "only the target's series are counted")
+
+	assert.Same(t, remote, WithFilters(remote, metricsql.LabelFilter{Label: "cluster", Value: "a"}))
+}
@@ -0,0 +1,41 @@
+package prometheus
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/prometheus/prometheus/promql"
+)
+
+// Restarts returns when processes started between from and to, as reported by
+// a query of their start times such as
+// process_start_time_seconds{job="prometheus"}.
+func Restarts(ctx context.Context, client Client, query string, from, to time.Time, interval time.Duration) ([]time.Time, error) {
+	vectors, _, err := client.QueryExpr(ctx, query, from, to, interval)
+	if err != nil {
+		return nil, fmt.Errorf("querying start times: %w", err)
+	}
+
+	return startTimes(vectors, from, to), nil
+}
+
+// startTimes returns the distinct sample values of vectors, read as Unix
+// timestamps in seconds, that fall after from and not after to.
+func startTimes(vectors map[int64]promql.Vector, from, to time.Time) []time.Time {
+	seen := make(map[int64]time.Time)
+
+	for _, vector := range vectors {
+		for _, sample := range vector {
+			t := time.UnixMilli(int64(math.Round(sample.F * 1000))).UTC()
+			if t.After(from) && !t.After(to) {
+				seen[t.UnixMilli()] = t
+			}
+		}
+	}
+
+	return slices.SortedFunc(maps.Values(seen), time.Time.Compare)
+}
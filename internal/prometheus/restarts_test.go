@@ -0,0 +1,40 @@
+package prometheus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartTimes(t *testing.T) {
+	var (
+		from    = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		to      = from.Add(24 * time.Hour)
+		primary = labels.FromStrings("job", "prometheus", "instance", "a")
+		replica = labels.FromStrings("job", "prometheus", "instance", "b")
+		started = func(t time.Time) float64 { return float64(t.UnixMilli()) / 1000 }
+	)
+
+	vectors := map[int64]promql.Vector{
+		from.UnixMilli(): {
+			{Metric: primary, F: started(from.Add(-72 * time.Hour))},
+			{Metric: replica, F: started(from.Add(-72 * time.Hour))},
+		},
+		from.Add(6 * time.Hour).UnixMilli(): {
+			{Metric: primary, F: started(from.Add(5*time.Hour + 500*time.Millisecond))},
+			{Metric: replica, F: started(from.Add(-72 * time.Hour))},
+		},
+		from.Add(12 * time.Hour).UnixMilli(): {
+			{Metric: primary, F: started(from.Add(5*time.Hour + 500*time.Millisecond))},
+			{Metric: replica, F: started(from.Add(11 * time.Hour))},
+		},
+	}
+
+	assert.Equal(t, []time.Time{
+		from.Add(5*time.Hour + 500*time.Millisecond),
+		from.Add(11 * time.Hour),
+	}, startTimes(vectors, from, to))
+}
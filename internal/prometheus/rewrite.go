@@ -27,3 +27,33 @@ func RewriteExpr(expr string, filters ...metricsql.LabelFilter) (string, error)
 
 	return string(parsed.AppendString(nil)), nil
 }
+
+// MetricNames returns the metric names of every series selector in expr, with
+// an empty name for selectors that don't match on a single metric name.
+func MetricNames(expr string) ([]string, error) {
+	parsed, err := metricsql.Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	metricsql.VisitAll(parsed, func(e metricsql.Expr) {
+		if metric, ok := e.(*metricsql.MetricExpr); ok {
+			names = append(names, metricName(metric))
+		}
+	})
+
+	return names, nil
+}
+
+func metricName(me *metricsql.MetricExpr) string {
+	if len(me.LabelFilterss) == 0 || len(me.LabelFilterss[0]) == 0 {
+		return ""
+	}
+
+	if f := me.LabelFilterss[0][0]; f.Label == "__name__" && !f.IsRegexp && !f.IsNegative {
+		return f.Value
+	}
+
+	return ""
+}
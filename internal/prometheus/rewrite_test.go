@@ -41,3 +41,37 @@ func TestRewriteExpr(t *testing.T) {
 		})
 	}
 }
+
+func TestMetricNames(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		expr     string
+		expected []string
+	}{
+		{
+			name:     "single selector",
+			expr:     `up == 0`,
+			expected: []string{"up"},
+		},
+		{
+			name:     "nested selectors",
+			expr:     `count(ALERTS{alertstate="firing"}) by (cluster) > 5 and on(cluster) max_over_time(up[5m]) == 1`,
+			expected: []string{"ALERTS", "up"},
+		},
+		{
+			name:     "selector without metric name",
+			expr:     `count({job="api"})`,
+			expected: []string{""},
+		},
+		{
+			name: "no selectors",
+			expr: `vector(1)`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MetricNames(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package vmrule
 
 import (
 	"fmt"
+	"maps"
 	"os"
 
 	v1beta1 "github.com/VictoriaMetrics/operator/api/operator/v1beta1"
@@ -38,29 +39,149 @@ func parseVMRuleFile(filePath string) ([]v1beta1.RuleGroup, error) {
 	return vmRule.Spec.Groups, nil
 }
 
+// ParseRecordingRules returns every recording rule in the file, with group
+// labels applied.
+func ParseRecordingRules(filePath string) ([]rulefmt.Rule, error) {
+	groups, err := parseVMRuleFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("parsing VMRule file: %w", err)
+	}
+
+	var recordings []rulefmt.Rule
+	for _, group := range groups {
+		for _, r := range group.Rules {
+			if r.Record == "" {
+				continue
+			}
+
+			rule, err := convertRule(group, r)
+			if err != nil {
+				return nil, fmt.Errorf("recording rule %q: %w", r.Record, err)
+			}
+
+			recordings = append(recordings, *rule)
+		}
+	}
+
+	return recordings, nil
+}
+
+// ParseAlertRules returns every alerting rule in the file, with group labels
+// applied.
+func ParseAlertRules(filePath string) ([]rulefmt.Rule, error) {
+	groups, err := parseVMRuleFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("parsing VMRule file: %w", err)
+	}
+
+	var alerts []rulefmt.Rule
+	for _, group := range groups {
+		for _, r := range group.Rules {
+			if r.Alert == "" {
+				continue
+			}
+
+			rule, err := convertRule(group, r)
+			if err != nil {
+				return nil, fmt.Errorf("alert rule %q: %w", r.Alert, err)
+			}
+
+			alerts = append(alerts, *rule)
+		}
+	}
+
+	return alerts, nil
+}
+
+// ParseRuleGroup returns the recording and alerting rules of a group in the
+// order they are evaluated, with group labels applied.
+func ParseRuleGroup(filePath string, groupName string) ([]rulefmt.Rule, error) {
+	groups, err := parseVMRuleFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("parsing VMRule file: %w", err)
+	}
+
+	for _, group := range groups {
+		if group.Name != groupName {
+			continue
+		}
+
+		rules := make([]rulefmt.Rule, 0, len(group.Rules))
+		for _, r := range group.Rules {
+			rule, err := convertRule(group, r)
+			if err != nil {
+				return nil, fmt.Errorf("rule %q: %w", r.Alert+r.Record, err)
+			}
+
+			rules = append(rules, *rule)
+		}
+
+		return rules, nil
+	}
+
+	return nil, fmt.Errorf("group %q not found", groupName)
+}
+
 func findVMAlertRule(groups []v1beta1.RuleGroup, alertName string) (*rulefmt.Rule, error) {
 	for _, group := range groups {
 		for _, r := range group.Rules {
 			if r.Alert == alertName {
-				var forDur model.Duration
-				if r.For != "" {
-					parsed, err := model.ParseDuration(r.For)
-					if err != nil {
-						return nil, fmt.Errorf("parsing duration %q: %w", r.For, err)
-					}
-					forDur = parsed
-				}
-
-				return &rulefmt.Rule{
-					Alert:       r.Alert,
-					Expr:        r.Expr,
-					For:         forDur,
-					Labels:      r.Labels,
-					Annotations: r.Annotations,
-				}, nil
+				return convertRule(group, r)
 			}
 		}
 	}
 
 	return nil, fmt.Errorf("alert %q not found", alertName)
 }
+
+func convertRule(group v1beta1.RuleGroup, r v1beta1.Rule) (*rulefmt.Rule, error) {
+	forDur, err := parseDuration(r.For)
+	if err != nil {
+		return nil, err
+	}
+
+	keepFiringFor, err := parseDuration(r.KeepFiringFor)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rulefmt.Rule{
+		Record:        r.Record,
+		Alert:         r.Alert,
+		Expr:          r.Expr,
+		For:           forDur,
+		KeepFiringFor: keepFiringFor,
+		Labels:        mergeLabels(r.Labels, group.Labels),
+		Annotations:   r.Annotations,
+	}, nil
+}
+
+// mergeLabels applies group labels on top of rule labels. As in vmalert, group
+// labels take priority over rule labels with the same name.
+func mergeLabels(ruleLabels, groupLabels map[string]string) map[string]string {
+	if len(groupLabels) == 0 {
+		return ruleLabels
+	}
+
+	merged := maps.Clone(ruleLabels)
+	if merged == nil {
+		merged = make(map[string]string, len(groupLabels))
+	}
+
+	maps.Copy(merged, groupLabels)
+
+	return merged
+}
+
+func parseDuration(s string) (model.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	parsed, err := model.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("parsing duration %q: %w", s, err)
+	}
+
+	return parsed, nil
+}
@@ -2,7 +2,10 @@ package vmrule
 
 import (
 	"testing"
+	"time"
 
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/rulefmt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -62,6 +65,12 @@ func TestParseAlertRule(t *testing.T) {
 			alertName: "BadDuration",
 			wantErr:   "parsing duration",
 		},
+		{
+			name:      "invalid keep_firing_for duration",
+			filePath:  "testdata/vmrule-invalid-duration.yml",
+			alertName: "BadKeepFiringFor",
+			wantErr:   "parsing duration",
+		},
 	} {
 		t.Run(tt.name, func(t *testing.T) {
 			rule, err := ParseAlertRule(tt.filePath, tt.alertName)
@@ -89,9 +98,87 @@ func TestParseAlertRule_forDuration(t *testing.T) {
 	assert.Zero(t, rule.For, "expected zero For duration when not specified")
 }
 
+func TestParseAlertRule_keepFiringFor(t *testing.T) {
+	rule, err := ParseAlertRule("testdata/vmrule-valid.yml", "FlappingTarget")
+	require.NoError(t, err)
+	assert.Equal(t, model.Duration(15*time.Minute), rule.KeepFiringFor)
+
+	rule, err = ParseAlertRule("testdata/vmrule-valid.yml", "HighLatency")
+	require.NoError(t, err)
+	assert.Zero(t, rule.KeepFiringFor, "expected zero KeepFiringFor when not specified")
+}
+
 func TestParseAlertRule_labelsAndAnnotations(t *testing.T) {
 	rule, err := ParseAlertRule("testdata/vmrule-valid.yml", "HighLatency")
 	require.NoError(t, err)
 	assert.Equal(t, "critical", rule.Labels["severity"])
 	assert.Equal(t, "High latency detected", rule.Annotations["summary"])
 }
+
+func TestParseAlertRule_groupLabels(t *testing.T) {
+	rule, err := ParseAlertRule("testdata/vmrule-valid.yml", "DiskFull")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"component": "disk",
+		"severity":  "page",
+		"team":      "storage",
+	}, rule.Labels)
+
+	rule, err = ParseAlertRule("testdata/vmrule-valid.yml", "HighLatency")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"severity": "critical"}, rule.Labels)
+}
+
+func TestParseRecordingRules(t *testing.T) {
+	recordings, err := ParseRecordingRules("testdata/vmrule-valid.yml")
+	require.NoError(t, err)
+	assert.Equal(t, []rulefmt.Rule{
+		{
+			Record: "job:errors:rate5m",
+			Expr:   "sum(rate(errors_total[5m])) by (job)",
+			Labels: map[string]string{"team": "api"},
+		},
+	}, recordings)
+
+	_, err = ParseRecordingRules("/nonexistent/path/rules.yaml")
+	assert.ErrorContains(t, err, "reading file")
+}
+
+func TestParseRuleGroup(t *testing.T) {
+	rules, err := ParseRuleGroup("testdata/vmrule-valid.yml", "recording-group")
+	require.NoError(t, err)
+	assert.Equal(t, []rulefmt.Rule{
+		{
+			Record: "job:errors:rate5m",
+			Expr:   "sum(rate(errors_total[5m])) by (job)",
+			Labels: map[string]string{"team": "api"},
+		},
+		{
+			Alert:  "HighErrorRate",
+			Expr:   "job:errors:rate5m > 0.05",
+			For:    model.Duration(5 * time.Minute),
+			Labels: map[string]string{"team": "api"},
+		},
+	}, rules)
+
+	_, err = ParseRuleGroup("testdata/vmrule-valid.yml", "missing-group")
+	assert.ErrorContains(t, err, `group "missing-group" not found`)
+
+	_, err = ParseRuleGroup("testdata/vmrule-invalid-duration.yml", "test")
+	assert.ErrorContains(t, err, "parsing duration")
+}
+
+func TestParseAlertRules(t *testing.T) {
+	rules, err := ParseAlertRules("testdata/vmrule-valid.yml")
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(rules))
+	for _, r := range rules {
+		names = append(names, r.Alert)
+	}
+
+	assert.Equal(t, []string{"HighLatency", "LowAvailability", "DiskFull", "FlappingTarget", "HighErrorRate"}, names)
+
+	_, err = ParseAlertRules("testdata/vmrule-invalid-duration.yml")
+	assert.ErrorContains(t, err, "parsing duration")
+}
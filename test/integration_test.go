@@ -24,6 +24,7 @@ import (
 
 	"github.com/steved/alertreplay/internal/alert"
 	"github.com/steved/alertreplay/internal/dashboard"
+	"github.com/steved/alertreplay/internal/evaluator"
 	"github.com/steved/alertreplay/internal/output"
 	promclient "github.com/steved/alertreplay/internal/prometheus"
 	"github.com/steved/alertreplay/internal/vmrule"
@@ -103,9 +104,11 @@ func TestIntegration(t *testing.T) {
 		urlBuilder,
 	)
 
-	alerts, err := alert.Evaluate(t.Context(), client, *rule, evalFrom, evalTo, evaluationInterval, urlBuilder)
+	result, err := alert.Evaluate(t.Context(), client, *rule, evalFrom, evalTo, evaluationInterval, urlBuilder, evaluator.Restarts{})
 	require.NoError(t, err)
 
+	alerts := result.Alerts
+
 	freezeAlerts(alerts, rule.Expr, urlBuilder)
 
 	var buf bytes.Buffer
@@ -177,6 +180,7 @@ func freezeAlerts(alerts []alert.Alert, expr string, urlBuilder dashboard.URLBui
 		alert := &alerts[i]
 		delete(alert.Labels, "instance")
 
+		alert.PendingAt = frozenOpened
 		alert.OpenedAt = frozenOpened
 		if alert.ResolvedAt != nil {
 			alert.ResolvedAt = new(frozenResolved)
@@ -299,13 +303,13 @@ func waitForResolvedAlert(
 			t.Fatal("timed out waiting for a resolved alert in evaluation")
 		case <-ticker.C:
 			to := time.Now().UTC()
-			alerts, err := alert.Evaluate(ctx, client, rule, from, to, interval, urlBuilder)
+			result, err := alert.Evaluate(ctx, client, rule, from, to, interval, urlBuilder, evaluator.Restarts{})
 			if err != nil {
 				t.Logf("evaluation poll error (retrying): %v", err)
 				continue
 			}
 
-			for _, alert := range alerts {
+			for _, alert := range result.Alerts {
 				if alert.ResolvedAt != nil {
 					t.Logf("resolved alert observed in evaluation at %s", to.Format(time.RFC3339))
 					return to